@@ -0,0 +1,135 @@
+package deep
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"time"
+)
+
+// ArchiveFormat is the format of archives compared by EqualArchive.
+type ArchiveFormat int
+
+const (
+	// ArchiveTar is a tar archive. Compressed archives, like .tar.gz, must be
+	// decompressed first, for example with gzip.NewReader.
+	ArchiveTar ArchiveFormat = iota
+
+	// ArchiveZip is a zip archive.
+	ArchiveZip
+)
+
+// archiveEntry is an archive entry compared by EqualArchive. The field names
+// are the names that can be ignored.
+type archiveEntry struct {
+	Mode     string
+	Size     int64
+	ModTime  time.Time
+	Uid      int
+	Gid      int
+	Uname    string
+	Gname    string
+	Linkname string
+	SHA256   string
+}
+
+// EqualArchive compares archives a and b in the given format and returns a
+// list of differences, or nil if there are none. Entries are compared by name,
+// metadata, and content (SHA-256 hash). Metadata fields named in ignore are not
+// compared: Mode, Size, ModTime, Uid, Gid, Uname, Gname, Linkname, or SHA256.
+// For example, ignore ModTime when archives are built at different times.
+// Differences are reported by entry name, like "map[bin/run].Size: 10 != 12".
+// An error is returned if either archive cannot be read.
+//
+// Zip archives are read into memory because the zip format must be read from
+// the end.
+func EqualArchive(a, b io.Reader, format ArchiveFormat, ignore ...string) ([]string, error) {
+	aEntries, err := readArchive(a, format, ignore)
+	if err != nil {
+		return nil, err
+	}
+	bEntries, err := readArchive(b, format, ignore)
+	if err != nil {
+		return nil, err
+	}
+	return Equal(aEntries, bEntries), nil
+}
+
+// readArchive returns all entries in archive r keyed by name.
+func readArchive(r io.Reader, format ArchiveFormat, ignore []string) (map[string]archiveEntry, error) {
+	entries := map[string]archiveEntry{}
+	add := func(name string, e archiveEntry, content io.Reader) error {
+		h := sha256.New()
+		if _, err := io.Copy(h, content); err != nil {
+			return err
+		}
+		e.SHA256 = fmt.Sprintf("%x", h.Sum(nil))
+		v := reflect.ValueOf(&e).Elem()
+		for _, field := range ignore {
+			if f := v.FieldByName(field); f.IsValid() {
+				f.Set(reflect.Zero(f.Type()))
+			}
+		}
+		entries[name] = e
+		return nil
+	}
+
+	switch format {
+	case ArchiveTar:
+		tr := tar.NewReader(r)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				return entries, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			e := archiveEntry{
+				Mode:     hdr.FileInfo().Mode().String(),
+				Size:     hdr.Size,
+				ModTime:  hdr.ModTime,
+				Uid:      hdr.Uid,
+				Gid:      hdr.Gid,
+				Uname:    hdr.Uname,
+				Gname:    hdr.Gname,
+				Linkname: hdr.Linkname,
+			}
+			if err := add(hdr.Name, e, tr); err != nil {
+				return nil, err
+			}
+		}
+	case ArchiveZip:
+		buf, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		zr, err := zip.NewReader(bytes.NewReader(buf), int64(len(buf)))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range zr.File {
+			e := archiveEntry{
+				Mode:    f.Mode().String(),
+				Size:    int64(f.UncompressedSize64),
+				ModTime: f.Modified,
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			err = add(f.Name, e, rc)
+			rc.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+		return entries, nil
+	}
+	return nil, fmt.Errorf("invalid ArchiveFormat: %d", format)
+}
@@ -0,0 +1,104 @@
+package deep_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+)
+
+func makeTar(t *testing.T, modTime time.Time, files map[string]string) *bytes.Buffer {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), ModTime: modTime}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func makeZip(t *testing.T, modTime time.Time, files map[string]string) *bytes.Buffer {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Modified: modTime})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestEqualArchive(t *testing.T) {
+	t1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	files := map[string]string{"README": "hello", "bin/run": "#!/bin/sh"}
+
+	for _, format := range []deep.ArchiveFormat{deep.ArchiveTar, deep.ArchiveZip} {
+		build := makeTar
+		if format == deep.ArchiveZip {
+			build = makeZip
+		}
+
+		diff, err := deep.EqualArchive(build(t, t1, files), build(t, t1, files), format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(diff) != 0 {
+			t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+		}
+
+		// Different ModTime, ignored
+		diff, err = deep.EqualArchive(build(t, t1, files), build(t, t2, files), format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(diff) != 2 {
+			t.Fatalf("expected 2 diff, got %d: %s", len(diff), diff)
+		}
+		diff, err = deep.EqualArchive(build(t, t1, files), build(t, t2, files), format, "ModTime")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(diff) != 0 {
+			t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+		}
+
+		// Different content and missing entry
+		diff, err = deep.EqualArchive(
+			build(t, t1, files),
+			build(t, t1, map[string]string{"README": "bye!!"}),
+			format, "ModTime", "SHA256")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(diff) != 1 {
+			t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+		}
+		if !strings.HasPrefix(diff[0], "map[bin/run]: {") || !strings.HasSuffix(diff[0], "} != <does not have key>") {
+			t.Errorf("wrong diff: %s", diff[0])
+		}
+	}
+
+	if _, err := deep.EqualArchive(&bytes.Buffer{}, &bytes.Buffer{}, deep.ArchiveZip); err == nil {
+		t.Error("expected error reading invalid zip")
+	}
+}
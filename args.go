@@ -0,0 +1,50 @@
+package deep
+
+import "strings"
+
+// cmdArgs are command-line arguments parsed by parseArgs.
+type cmdArgs struct {
+	Flags map[string][]string
+	Args  []string
+}
+
+// EqualArgs compares command-line arguments like os.Args or exec.Cmd.Args.
+// valueFlags are the flags, with their dashes, that take a value, like "-o"
+// and "--output". Those flags are equal if their values are equal, regardless
+// of their order or whether they are written "--flag=x" or "--flag x". All other
+// arguments, including flags that are not valueFlags, are compared in order.
+// Arguments after "--" are never treated as flags. Differences are reported as
+// "Flags.map[--output].slice[0]: a != b" or "Args.slice[1]: a != b".
+func EqualArgs(a, b []string, valueFlags ...string) []string {
+	return Equal(parseArgs(a, valueFlags), parseArgs(b, valueFlags))
+}
+
+// parseArgs splits args into valueFlags and the other arguments.
+func parseArgs(args []string, valueFlags []string) cmdArgs {
+	isValueFlag := map[string]bool{}
+	for _, f := range valueFlags {
+		isValueFlag[f] = true
+	}
+	p := cmdArgs{
+		Flags: map[string][]string{},
+		Args:  []string{},
+	}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			p.Args = append(p.Args, args[i:]...)
+			break
+		}
+		if isValueFlag[arg] && i+1 < len(args) {
+			p.Flags[arg] = append(p.Flags[arg], args[i+1])
+			i++
+			continue
+		}
+		if eq := strings.Index(arg, "="); eq > 0 && isValueFlag[arg[:eq]] {
+			p.Flags[arg[:eq]] = append(p.Flags[arg[:eq]], arg[eq+1:])
+			continue
+		}
+		p.Args = append(p.Args, arg)
+	}
+	return p
+}
@@ -0,0 +1,43 @@
+package deep_test
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestEqualArgs(t *testing.T) {
+	a := []string{"git", "log", "--format=%H", "-n", "10", "-v", "main"}
+	b := []string{"git", "log", "-n=10", "--format", "%H", "-v", "main"}
+	diff := deep.EqualArgs(a, b, "--format", "-n")
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+
+	// Without valueFlags, args are compared in order
+	diff = deep.EqualArgs(a, b)
+	if len(diff) == 0 {
+		t.Fatal("no diff")
+	}
+
+	// Different flag value and positional arg
+	b = []string{"git", "log", "-n", "20", "--format=%H", "-v", "dev"}
+	diff = deep.EqualArgs(a, b, "--format", "-n")
+	if len(diff) != 2 {
+		t.Fatalf("expected 2 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "Flags.map[-n].slice[0]: 10 != 20" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+	if diff[1] != "Args.slice[3]: main != dev" {
+		t.Errorf("wrong diff: %s", diff[1])
+	}
+
+	// Args after -- are not flags
+	a = []string{"run", "--", "-n", "1"}
+	b = []string{"run", "-n", "1", "--"}
+	diff = deep.EqualArgs(a, b, "-n")
+	if len(diff) == 0 {
+		t.Fatal("no diff")
+	}
+}
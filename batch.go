@@ -0,0 +1,123 @@
+package deep
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Batch is a list of named pairs of values to compare together, like the
+// cases of a table-driven test, so that the differences are reported in one
+// BatchReport instead of interleaved failures. The zero value is an empty
+// batch that compares with no flags.
+type Batch struct {
+	flags []interface{}
+	pairs []batchPair
+}
+
+type batchPair struct {
+	name string
+	a, b interface{}
+}
+
+// NewBatch returns an empty batch that compares every pair with flags, like
+// Equal(a, b, flags...).
+func NewBatch(flags ...interface{}) *Batch {
+	return &Batch{flags: flags}
+}
+
+// Add adds values a and b, named name, to the batch. Names should be unique;
+// the differences of pairs with the same name are reported together.
+func (batch *Batch) Add(name string, a, b interface{}) {
+	batch.pairs = append(batch.pairs, batchPair{name: name, a: a, b: b})
+}
+
+// Compare compares every pair in the order added. Each pair is compared like
+// Compare(a, b, flags...), so its differences are collapsed if DedupDiffs is
+// true and its warnings are returned by Diff.Warnings. MaxDiff is shared by
+// all pairs: once MaxDiff differences are found, the remaining pairs are not
+// compared and the report is truncated.
+func (batch *Batch) Compare() BatchReport {
+	r := BatchReport{Diffs: map[string]Diff{}}
+	left := maxDiff()
+	for _, p := range batch.pairs {
+		if left <= 0 {
+			r.Truncated = true
+			break
+		}
+		d, n := batch.compare(p, left)
+		left -= n
+		if len(d.Differences) == 0 && len(d.warnings) == 0 {
+			continue
+		}
+		pd := r.Diffs[p.name]
+		if len(pd.Differences) == 0 && len(d.Differences) > 0 {
+			r.Names = append(r.Names, p.name)
+		}
+		pd.Differences = append(pd.Differences, d.Differences...)
+		pd.warnings = append(pd.warnings, d.warnings...)
+		r.Diffs[p.name] = pd
+	}
+	return r
+}
+
+// compare compares pair p with at most max differences and returns them, and
+// the number of differences before they were collapsed.
+func (batch *Batch) compare(p batchPair, max int) (Diff, int) {
+	c := newCmp(batch.flags)
+	defer c.release()
+	c.maxDiff = max
+	c.compareValues(p.a, p.b)
+	n := len(c.diff)
+	c.dedup()
+	return Diff{Differences: c.details, warnings: c.warnings}, n
+}
+
+// BatchReport is the result of Batch.Compare.
+type BatchReport struct {
+	// Names are the names of the pairs with differences, in the order added.
+	Names []string
+
+	// Diffs are the differences and warnings of each pair in Names, and of
+	// pairs with warnings but no differences, by name.
+	Diffs map[string]Diff
+
+	// Truncated is true if some pairs were not compared because MaxDiff was
+	// reached.
+	Truncated bool
+}
+
+// Len returns the number of differences in all pairs.
+func (r BatchReport) Len() int {
+	n := 0
+	for _, d := range r.Diffs {
+		n += d.Len()
+	}
+	return n
+}
+
+// String returns the differences grouped by name, or "" if there are none,
+// like:
+//
+//	user: 1 diff
+//	  Name: alice != bob
+//	order: 2 diffs
+//	  Total: 10 != 12
+//	  Items.slice[1]: <no value> != pen
+func (r BatchReport) String() string {
+	var s strings.Builder
+	for _, name := range r.Names {
+		d := r.Diffs[name]
+		plural := "s"
+		if d.Len() == 1 {
+			plural = ""
+		}
+		fmt.Fprintf(&s, "%s: %d diff%s\n", name, d.Len(), plural)
+		for _, diff := range d.Strings() {
+			fmt.Fprintf(&s, "  %s\n", diff)
+		}
+	}
+	if r.Truncated {
+		fmt.Fprintf(&s, "(stopped after MaxDiff=%d diffs)\n", MaxDiff)
+	}
+	return s.String()
+}
@@ -0,0 +1,94 @@
+package deep_test
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestBatch(t *testing.T) {
+	type user struct {
+		Name string
+		Age  int
+	}
+	batch := deep.NewBatch()
+	batch.Add("alice", user{"alice", 30}, user{"alice", 31})
+	batch.Add("same", user{"bob", 40}, user{"bob", 40})
+	batch.Add("nil", nil, user{"carol", 50})
+	batch.Add("carol", user{"carol", 50}, user{"Carol", 51})
+
+	r := batch.Compare()
+	if r.Len() != 4 {
+		t.Fatalf("got %d diffs, expected 4: %v", r.Len(), r)
+	}
+	if diff := deep.Equal(r.Names, []string{"alice", "nil", "carol"}); diff != nil {
+		t.Error(diff)
+	}
+	if r.Truncated {
+		t.Error("Truncated is true")
+	}
+	expect := "alice: 1 diff\n" +
+		"  Age: 30 != 31\n" +
+		"nil: 1 diff\n" +
+		"  <nil pointer> != {carol 50}\n" +
+		"carol: 2 diffs\n" +
+		"  Name: carol != Carol\n" +
+		"  Age: 50 != 51\n"
+	if s := r.String(); s != expect {
+		t.Errorf("got:\n%s\nexpected:\n%s", s, expect)
+	}
+
+	// Empty
+	if r := deep.NewBatch().Compare(); r.Len() != 0 || r.String() != "" {
+		t.Errorf("empty batch: %v", r)
+	}
+}
+
+func TestBatchMaxDiff(t *testing.T) {
+	defer func(n int) { deep.MaxDiff = n }(deep.MaxDiff)
+	deep.MaxDiff = 3
+
+	batch := &deep.Batch{}
+	batch.Add("a", []int{1, 2}, []int{3, 4})
+	batch.Add("b", []int{1, 2}, []int{3, 4})
+	batch.Add("c", []int{1, 2}, []int{3, 4})
+
+	r := batch.Compare()
+	if r.Len() != 3 {
+		t.Fatalf("got %d diffs, expected 3: %v", r.Len(), r)
+	}
+	if r.Diffs["a"].Len() != 2 || r.Diffs["b"].Len() != 1 {
+		t.Errorf("wrong diffs: %v", r)
+	}
+	if _, ok := r.Diffs["c"]; ok {
+		t.Error("pair c compared after MaxDiff")
+	}
+	if !r.Truncated {
+		t.Error("Truncated is false")
+	}
+}
+
+func TestBatchDedupWarnings(t *testing.T) {
+	defer func(v bool) { deep.DedupDiffs = v }(deep.DedupDiffs)
+	deep.DedupDiffs = true
+
+	type plugin struct {
+		Name string
+		Run  func()
+	}
+	batch := deep.NewBatch()
+	batch.Add("ids", []int{1, 1, 1}, []int{2, 2, 2})
+	batch.Add("plugin", plugin{"a", func() {}}, plugin{"a", func() {}})
+
+	r := batch.Compare()
+	if diff := deep.Equal(r.Names, []string{"ids"}); diff != nil {
+		t.Error(diff)
+	}
+	if s := r.Diffs["ids"].Strings(); len(s) != 1 || s[0] != "slice[0]: 1 != 2 (and 2 more)" {
+		t.Errorf("got %q", s)
+	}
+	w := r.Diffs["plugin"].Warnings()
+	if len(w) != 1 || w[0].String() != "Run: cannot compare the reflect.Kind" {
+		t.Errorf("got warnings: %v", w)
+	}
+}
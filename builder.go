@@ -0,0 +1,68 @@
+package deep
+
+// DiffBuilder builds diffs in the same format as Equal for values that are
+// compared by hand, like C structs or FlatBuffers, and can compare other
+// values with Equal at the current path, so both kinds of diffs are merged
+// in one result. The path is a stack of names, like "Items", "slice[0]",
+// and "map[foo]", which are printed joined by "." like Equal.
+type DiffBuilder struct {
+	c *cmp
+}
+
+// NewDiffBuilder returns a DiffBuilder with an empty path that compares
+// values with flags, like Equal(a, b, flags...).
+func NewDiffBuilder(flags ...interface{}) *DiffBuilder {
+	return &DiffBuilder{c: newCmp(flags)}
+}
+
+// Push adds name to the end of the current path.
+func (db *DiffBuilder) Push(name string) {
+	db.c.push(name)
+}
+
+// Pop removes the last name from the current path.
+func (db *DiffBuilder) Pop() {
+	db.c.pop()
+}
+
+// Add adds a diff of values a and b at the current path, like "Items.slice[0]:
+// a != b". Values are formatted like Equal: with their String method if
+// UseStringer is true, redacted if the path matches a Redact pattern, and so
+// on. Add returns false if MaxDiff has been reached, which means the caller
+// should stop comparing. Diffs are not added after MaxDiff is reached.
+func (db *DiffBuilder) Add(a, b interface{}) bool {
+	if db.Full() {
+		return false
+	}
+	db.c.saveDiff(a, b)
+	return !db.Full()
+}
+
+// Compare compares a and b like Equal and adds their diffs at the current
+// path. It returns false if MaxDiff has been reached.
+func (db *DiffBuilder) Compare(a, b interface{}) bool {
+	if db.Full() {
+		return false
+	}
+	db.c.compareValues(a, b)
+	return !db.Full()
+}
+
+// Full returns true if MaxDiff diffs have been added.
+func (db *DiffBuilder) Full() bool {
+	return db.c.full()
+}
+
+// Diff returns the diffs added so far.
+func (db *DiffBuilder) Diff() Diff {
+	return Diff{
+		Differences: append([]Difference(nil), db.c.details...),
+		warnings:    append([]Warning(nil), db.c.warnings...),
+	}
+}
+
+// Strings returns the diffs added so far as they are returned by Equal, or
+// nil if there are none.
+func (db *DiffBuilder) Strings() []string {
+	return db.Diff().Strings()
+}
@@ -0,0 +1,74 @@
+package deep_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestDiffBuilder(t *testing.T) {
+	type header struct {
+		Version int
+		Tags    []string
+	}
+	// Hand-rolled comparison of two "C structs"
+	aRecs := []uint32{1, 2, 3}
+	bRecs := []uint32{1, 5, 3}
+
+	db := deep.NewDiffBuilder()
+	db.Push("Records")
+	for i := range aRecs {
+		db.Push(fmt.Sprintf("slice[%d]", i))
+		if aRecs[i] != bRecs[i] {
+			db.Add(aRecs[i], bRecs[i])
+		}
+		db.Pop()
+	}
+	db.Pop()
+
+	// Merged with Equal diffs
+	db.Push("Header")
+	db.Compare(header{1, []string{"a"}}, header{2, []string{"a", "b"}})
+	db.Pop()
+	db.Compare(nil, "x")
+
+	expect := []string{
+		"Records.slice[1]: 2 != 5",
+		"Header.Version: 1 != 2",
+		"Header.Tags.slice[1]: <no value> != b",
+		"<nil pointer> != x",
+	}
+	if diff := deep.Equal(db.Strings(), expect); diff != nil {
+		t.Error(diff)
+	}
+	if db.Diff().Len() != 4 {
+		t.Errorf("got Len %d, expected 4", db.Diff().Len())
+	}
+
+	if s := deep.NewDiffBuilder().Strings(); s != nil {
+		t.Errorf("got %v, expected nil", s)
+	}
+}
+
+func TestDiffBuilderMaxDiff(t *testing.T) {
+	defer func(n int) { deep.MaxDiff = n }(deep.MaxDiff)
+	deep.MaxDiff = 2
+
+	db := deep.NewDiffBuilder()
+	if !db.Add(1, 2) {
+		t.Error("Add returned false")
+	}
+	if db.Add(3, 4) {
+		t.Error("Add returned true at MaxDiff")
+	}
+	if !db.Full() {
+		t.Error("Full returned false")
+	}
+	if db.Add(5, 6) || db.Compare(7, 8) {
+		t.Error("returned true after MaxDiff")
+	}
+	if n := len(db.Strings()); n != 2 {
+		t.Errorf("got %d diffs, expected 2", n)
+	}
+}
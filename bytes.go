@@ -0,0 +1,30 @@
+package deep
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+)
+
+// equalsBytes reports []byte values a and b, which differ, as one diff with
+// the offset of the first difference; see BytesFormat.
+func (c *cmp) equalsBytes(a, b []byte) {
+	offset := 0
+	for offset < len(a) && offset < len(b) && a[offset] == b[offset] {
+		offset++
+	}
+	note := fmt.Sprintf("bytes differ at offset %d", offset)
+	c.saveNote(note, quoted(formatBytes(a)), quoted(formatBytes(b)))
+}
+
+// formatBytes returns p printed in BytesFormat.
+func formatBytes(p []byte) string {
+	switch BytesFormat {
+	case "hex":
+		return hex.EncodeToString(p)
+	case "base64":
+		return base64.StdEncoding.EncodeToString(p)
+	}
+	return strconv.Quote(string(p))
+}
@@ -0,0 +1,55 @@
+package deep_test
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestBytesFormat(t *testing.T) {
+	type msg struct {
+		Data []byte
+	}
+	a := msg{[]byte("hello")}
+	b := msg{[]byte("help")}
+
+	// Default: element diffs
+	diff := deep.Equal(a, b)
+	expect := []string{
+		"Data.slice[3]: 108 != 112",
+		"Data.slice[4]: 111 != <no value>",
+	}
+	if d := deep.Equal(diff, expect); d != nil {
+		t.Error(d)
+	}
+
+	defer func(f string) { deep.BytesFormat = f }(deep.BytesFormat)
+	for _, v := range []struct {
+		format string
+		expect string
+	}{
+		{"string", `Data: bytes differ at offset 3: "hello" != "help"`},
+		{"hex", "Data: bytes differ at offset 3: 68656c6c6f != 68656c70"},
+		{"base64", "Data: bytes differ at offset 3: aGVsbG8= != aGVscA=="},
+	} {
+		deep.BytesFormat = v.format
+		diff := deep.Equal(a, b)
+		if len(diff) != 1 {
+			t.Fatalf("%s: expected 1 diff, got %d: %v", v.format, len(diff), diff)
+		}
+		if diff[0] != v.expect {
+			t.Errorf("%s: got %s, expected %s", v.format, diff[0], v.expect)
+		}
+	}
+
+	// Prefix
+	deep.BytesFormat = "string"
+	diff = deep.Equal([]byte("ab"), []byte("abc"))
+	if len(diff) != 1 || diff[0] != `bytes differ at offset 2: "ab" != "abc"` {
+		t.Errorf("wrong diffs: %v", diff)
+	}
+
+	if diff := deep.Equal(a, msg{[]byte("hello")}); diff != nil {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,56 @@
+package deep
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+// CloudEventVolatileKeys are the CloudEvents attributes that EqualCloudEvent
+// ignores because they change every time an event is emitted.
+var CloudEventVolatileKeys = []string{"id", "time", "traceparent", "tracestate"}
+
+// EqualCloudEvent compares CloudEvents in structured JSON mode, like webhook
+// payloads, and returns a list of differences, or nil if there are none.
+// Attributes in CloudEventVolatileKeys and ignoreKeys are not compared. The
+// data payload is compared like EqualJSON: if the event has "data_base64"
+// that decodes to JSON, it is compared as JSON data. Differences are reported
+// by path, like "map[data].map[order_id]: 1 != 2". An error is returned if
+// either event is not valid JSON.
+func EqualCloudEvent(a, b []byte, ignoreKeys ...string) ([]string, error) {
+	aEvent, err := decodeCloudEvent(a, ignoreKeys)
+	if err != nil {
+		return nil, err
+	}
+	bEvent, err := decodeCloudEvent(b, ignoreKeys)
+	if err != nil {
+		return nil, err
+	}
+	return Equal(aEvent, bEvent), nil
+}
+
+// decodeCloudEvent decodes event p without the ignored keys and with JSON
+// data_base64 decoded into data.
+func decodeCloudEvent(p []byte, ignoreKeys []string) (interface{}, error) {
+	doc, err := decodeJSON(p)
+	if err != nil {
+		return nil, err
+	}
+	event, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("CloudEvent is not a JSON object")
+	}
+	for _, keys := range [][]string{CloudEventVolatileKeys, ignoreKeys} {
+		for _, k := range keys {
+			delete(event, k)
+		}
+	}
+	if s, ok := event["data_base64"].(string); ok {
+		if raw, err := base64.StdEncoding.DecodeString(s); err == nil {
+			if data, err := decodeJSON(raw); err == nil {
+				delete(event, "data_base64")
+				event["data"] = data
+			}
+		}
+	}
+	return event, nil
+}
@@ -0,0 +1,50 @@
+package deep_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestEqualCloudEvent(t *testing.T) {
+	a := []byte(`{
+		"specversion": "1.0", "type": "order.created", "source": "/orders",
+		"id": "a1", "time": "2024-01-01T00:00:00Z", "traceparent": "00-abc-01",
+		"datacontenttype": "application/json",
+		"data": {"order_id": 1, "total": 9.5}
+	}`)
+	b := []byte(`{
+		"specversion": "1.0", "type": "order.created", "source": "/orders",
+		"id": "b2", "time": "2024-06-01T00:00:00Z",
+		"datacontenttype": "application/json",
+		"data_base64": "` + base64.StdEncoding.EncodeToString([]byte(`{"total": 9.50, "order_id": 1}`)) + `"
+	}`)
+	diff, err := deep.EqualCloudEvent(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+
+	b = []byte(`{
+		"specversion": "1.0", "type": "order.updated", "source": "/orders",
+		"id": "b2", "datacontenttype": "application/json",
+		"data": {"order_id": 2, "total": 9.5}
+	}`)
+	diff, err = deep.EqualCloudEvent(a, b, "type")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "map[data].map[order_id]: 1 != 2" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+
+	if _, err := deep.EqualCloudEvent([]byte(`[]`), b); err == nil {
+		t.Error("expected error for non-object event")
+	}
+}
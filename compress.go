@@ -0,0 +1,84 @@
+package deep
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+)
+
+// decompressors are the registered decompressors by name.
+var decompressors = map[string]func([]byte) ([]byte, error){
+	"gzip": func(p []byte) ([]byte, error) {
+		r, err := gzip.NewReader(bytes.NewReader(p))
+		if err != nil {
+			return nil, err
+		}
+		return readAll(r)
+	},
+	"zlib": func(p []byte) ([]byte, error) {
+		r, err := zlib.NewReader(bytes.NewReader(p))
+		if err != nil {
+			return nil, err
+		}
+		return readAll(r)
+	},
+}
+
+func readAll(r io.ReadCloser) ([]byte, error) {
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// RegisterDecompressor registers a decompressor for []byte struct fields with
+// the tag `deep:"decompress=name"`. The values of these fields are decompressed
+// before comparing, so that the logical payloads are compared rather than bytes
+// that depend on the compressor version or level. Decompressors for "gzip" and
+// "zlib" are registered by default. Other formats, like zstd, must be registered
+// by the user, usually in an init func. A nil fn unregisters the name.
+//
+// RegisterDecompressor is not safe to call concurrently with Equal.
+func RegisterDecompressor(name string, fn func([]byte) ([]byte, error)) {
+	if fn == nil {
+		delete(decompressors, name)
+		return
+	}
+	decompressors[name] = fn
+}
+
+// equalsDecompressed compares []byte values a and b after decompressing them
+// with the named decompressor. If only one value cannot be decompressed, the
+// error is reported as a diff. If neither can, the raw values are compared.
+func (c *cmp) equalsDecompressed(a, b reflect.Value, name string, level int) {
+	decompress, ok := decompressors[name]
+	if !ok || a.Type().Kind() != reflect.Slice || a.Type().Elem().Kind() != reflect.Uint8 {
+		c.logError(ErrNotHandled)
+		c.equals(a, b, level)
+		return
+	}
+	if a.IsNil() || b.IsNil() {
+		c.equals(a, b, level)
+		return
+	}
+	aData, aErr := decompress(a.Bytes())
+	bData, bErr := decompress(b.Bytes())
+	if aErr != nil && bErr != nil {
+		c.equals(a, b, level)
+		return
+	}
+	if aErr != nil || bErr != nil {
+		aVal, bVal := marker("<decompressed>"), marker("<decompressed>")
+		if aErr != nil {
+			aVal = marker(fmt.Sprintf("<%s error: %s>", name, aErr))
+		}
+		if bErr != nil {
+			bVal = marker(fmt.Sprintf("<%s error: %s>", name, bErr))
+		}
+		c.saveDiff(aVal, bVal)
+		return
+	}
+	c.equals(reflect.ValueOf(aData), reflect.ValueOf(bData), level)
+}
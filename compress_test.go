@@ -0,0 +1,76 @@
+package deep_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func gzipBytes(t *testing.T, p []byte, level int) []byte {
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecompress(t *testing.T) {
+	type Entry struct {
+		Key  string
+		Data []byte `deep:"decompress=gzip"`
+		Raw  []byte
+	}
+	payload := bytes.Repeat([]byte("hello world "), 100)
+	a := Entry{Key: "foo", Data: gzipBytes(t, payload, gzip.BestSpeed)}
+	b := Entry{Key: "foo", Data: gzipBytes(t, payload, gzip.BestCompression)}
+	if bytes.Equal(a.Data, b.Data) {
+		t.Fatal("test requires different compressed bytes")
+	}
+	diff := deep.Equal(a, b)
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+
+	// Different payloads
+	b.Data = gzipBytes(t, []byte("hello"), gzip.BestSpeed)
+	diff = deep.Equal(a, b)
+	if len(diff) == 0 {
+		t.Fatal("no diff")
+	}
+
+	// Only one is not gzip
+	b.Data = []byte("hello")
+	diff = deep.Equal(a, b)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "Data: <decompressed> != <gzip error: unexpected EOF>" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+
+	// Custom decompressor
+	type Custom struct {
+		Data []byte `deep:"decompress=upper"`
+	}
+	deep.RegisterDecompressor("upper", func(p []byte) ([]byte, error) {
+		if len(p) == 0 {
+			return nil, errors.New("empty")
+		}
+		return bytes.ToUpper(p), nil
+	})
+	defer deep.RegisterDecompressor("upper", nil)
+	diff = deep.Equal(Custom{[]byte("foo")}, Custom{[]byte("FOO")})
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+}
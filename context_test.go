@@ -0,0 +1,52 @@
+package deep_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+// canceler is different from every value and cancels a context when compared.
+type canceler struct {
+	cancel func()
+}
+
+func (c canceler) Compare(other canceler) int {
+	c.cancel()
+	return 1
+}
+
+func TestEqualContext(t *testing.T) {
+	defer func(n int) { deep.MaxDiff = n }(deep.MaxDiff)
+	deep.MaxDiff = 100000
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a := make([]canceler, 10000)
+	for i := range a {
+		a[i].cancel = cancel
+	}
+	diff, err := deep.EqualContext(ctx, a, a[:len(a)-1:len(a)-1])
+	if err != context.Canceled {
+		t.Errorf("got error %v, expected context.Canceled", err)
+	}
+	if len(diff) == 0 || len(diff) > 100 {
+		t.Errorf("got %d diffs, expected 1-100", len(diff))
+	}
+
+	// Done before comparing
+	diff, err = deep.EqualContext(ctx, 1, 2)
+	if err != context.Canceled || diff != nil {
+		t.Errorf("got %v, %v; expected nil, context.Canceled", diff, err)
+	}
+
+	// Not done
+	diff, err = deep.EqualContext(context.Background(), []int{1, 2}, []int{1, 3})
+	if err != nil {
+		t.Error(err)
+	}
+	if len(diff) != 1 || diff[0] != "slice[1]: 2 != 3" {
+		t.Errorf("wrong diffs: %v", diff)
+	}
+}
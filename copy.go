@@ -0,0 +1,123 @@
+package deep
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Copy returns a deep copy of v: pointers, slices, maps, and interfaces are
+// copied recursively, so the copy does not share memory with v, except for
+// unexported fields, which are copied shallowly, and channels and funcs,
+// which are not copied. Values that can be locked, like pointers to structs
+// that embed a sync.Mutex, are locked while they are copied, like LockValues,
+// and struct types in packages sync and sync/atomic are copied as zero values.
+// Cycles through pointers, maps, and slices are preserved.
+func Copy(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	cp := copier{seen: map[copyKey]reflect.Value{}}
+	return cp.copy(reflect.ValueOf(v)).Interface()
+}
+
+type copier struct {
+	seen map[copyKey]reflect.Value // copies of pointers, maps, and slices
+}
+
+// copyKey is a pointer, map, or slice that has been copied. A slice is the
+// same only if it has the same length, not if it is a shorter slice of the
+// same array.
+type copyKey struct {
+	p uintptr
+	t reflect.Type
+	n int
+}
+
+func (cp copier) copy(v reflect.Value) reflect.Value {
+	t := v.Type()
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return reflect.Zero(t)
+		}
+		k := copyKey{p: v.Pointer(), t: t}
+		if c, ok := cp.seen[k]; ok {
+			return c
+		}
+		c := reflect.New(t.Elem())
+		cp.seen[k] = c
+		if v.CanInterface() {
+			switch l := v.Interface().(type) {
+			case rLocker:
+				l.RLock()
+				defer l.RUnlock()
+			case sync.Locker:
+				l.Lock()
+				defer l.Unlock()
+			}
+		}
+		c.Elem().Set(cp.copy(v.Elem()))
+		return c
+	case reflect.Interface:
+		c := reflect.New(t).Elem()
+		if !v.IsNil() {
+			c.Set(cp.copy(v.Elem()))
+		}
+		return c
+	case reflect.Struct:
+		c := reflect.New(t).Elem()
+		if t.PkgPath() == "sync" || t.PkgPath() == "sync/atomic" {
+			return c
+		}
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).PkgPath != "" {
+				// Unexported fields cannot be set individually, so copy the
+				// whole struct, including any locks, which are read racily.
+				c.Set(v)
+				break
+			}
+		}
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).PkgPath == "" {
+				c.Field(i).Set(cp.copy(v.Field(i)))
+			}
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return reflect.Zero(t)
+		}
+		k := copyKey{p: v.Pointer(), t: t, n: v.Len()}
+		if c, ok := cp.seen[k]; ok {
+			return c
+		}
+		c := reflect.MakeSlice(t, v.Len(), v.Len())
+		cp.seen[k] = c
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(cp.copy(v.Index(i)))
+		}
+		return c
+	case reflect.Array:
+		c := reflect.New(t).Elem()
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(cp.copy(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return reflect.Zero(t)
+		}
+		k := copyKey{p: v.Pointer(), t: t}
+		if c, ok := cp.seen[k]; ok {
+			return c
+		}
+		c := reflect.MakeMapWithSize(t, v.Len())
+		cp.seen[k] = c
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), cp.copy(iter.Value()))
+		}
+		return c
+	}
+	return v
+}
@@ -0,0 +1,101 @@
+package deep_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+type node struct {
+	Name     string
+	Next     *node
+	Tags     []string
+	Attrs    map[string]interface{}
+	Counts   [2]int
+	internal *int
+}
+
+func TestCopy(t *testing.T) {
+	n := 1
+	a := &node{
+		Name:     "a",
+		Tags:     []string{"x", "y"},
+		Attrs:    map[string]interface{}{"k": []int{1}},
+		Counts:   [2]int{1, 2},
+		internal: &n,
+	}
+	a.Next = a // cycle
+
+	c := deep.Copy(a).(*node)
+	if c == a {
+		t.Fatal("Copy returned same pointer")
+	}
+	if c.Next != c {
+		t.Error("cycle not preserved")
+	}
+	if c.internal != a.internal {
+		t.Error("unexported field not copied shallowly")
+	}
+	if diff := deep.Equal(c, a); diff != nil {
+		t.Error(diff)
+	}
+
+	// Copy does not share memory
+	c.Tags[0] = "z"
+	c.Attrs["k"].([]int)[0] = 2
+	if a.Tags[0] != "x" || a.Attrs["k"].([]int)[0] != 1 {
+		t.Error("copy shares memory with original")
+	}
+
+	if deep.Copy(nil) != nil {
+		t.Error("Copy(nil) is not nil")
+	}
+	var nilSlice []int
+	if deep.Copy(nilSlice).([]int) != nil {
+		t.Error("copy of nil slice is not nil")
+	}
+
+	// Cycles through slices and maps
+	s := []interface{}{nil}
+	s[0] = s
+	sc := deep.Copy(s).([]interface{})
+	if &sc[0] == &s[0] || &sc[0].([]interface{})[0] != &sc[0] {
+		t.Error("slice cycle not preserved")
+	}
+	m := map[string]interface{}{}
+	m["self"] = m
+	mc := deep.Copy(m).(map[string]interface{})
+	mc["x"] = 1
+	if _, ok := m["x"]; ok {
+		t.Error("copy shares memory with original map")
+	}
+	if _, ok := mc["self"].(map[string]interface{})["x"]; !ok {
+		t.Error("map cycle not preserved")
+	}
+}
+
+func TestCopyValues(t *testing.T) {
+	defer func(v bool) { deep.CopyValues = v }(deep.CopyValues)
+	deep.CopyValues = true
+
+	a := &counter{N: 1}
+	b := &counter{N: 2}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			b.Lock()
+			b.N++
+			b.Unlock()
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		diff := deep.Equal(a, b)
+		if len(diff) != 1 {
+			t.Fatalf("expected 1 diff, got %d: %v", len(diff), diff)
+		}
+	}
+	wg.Wait()
+}
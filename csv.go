@@ -0,0 +1,36 @@
+package deep
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// WriteCSV writes the differences to w as CSV with a header row and columns
+// path, expected, actual, and kind, like:
+//
+//	path,expected,actual,kind
+//	Address.City,foo,bar,changed
+//	Labels.map[env],prod,<does not have key>,missing key
+//
+// for triaging large comparisons in a spreadsheet. Expected and actual are
+// the A and B values of the differences, and kind is the category counted by
+// Stats: "changed", "missing key", "extra element", or "type mismatch". A
+// note, like "bytes differ at offset 2", is printed after the path, and
+// summary diffs, like "5 elements differ", are printed as the expected value.
+func (d Diff) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"path", "expected", "actual", "kind"})
+	for _, diff := range d.Differences {
+		path := joinPath(diff.Path)
+		if diff.note != "" {
+			path += " (" + diff.note + ")"
+		}
+		expected, actual := diff.A, diff.B
+		if diff.text != "" {
+			expected = diff.values()
+		}
+		cw.Write([]string{path, expected, actual, diff.category.String()})
+	}
+	cw.Flush()
+	return cw.Error()
+}
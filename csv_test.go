@@ -0,0 +1,43 @@
+package deep_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestWriteCSV(t *testing.T) {
+	type T struct {
+		City   string
+		Labels map[string]string
+		Items  []int
+		Value  interface{}
+	}
+	a := T{City: "foo, bar", Labels: map[string]string{"env": "prod"}, Items: []int{1, 2}, Value: 1}
+	b := T{City: `"baz"`, Labels: map[string]string{}, Items: []int{1}, Value: "1"}
+	var buf bytes.Buffer
+	if err := deep.Compare(a, b).WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `path,expected,actual,kind
+City,"foo, bar","""baz""",changed
+Labels.map[env],prod,<does not have key>,missing key
+Items.slice[1],2,<no value>,extra element
+Value,int,string,type mismatch
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nexpected:\n%s", got, want)
+	}
+
+	if err := deep.Compare(a, b).WriteCSV(errWriter{}); err == nil {
+		t.Error("no error")
+	}
+}
+
+type errWriter struct{}
+
+func (errWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
@@ -0,0 +1,40 @@
+package deep
+
+import "fmt"
+
+// dedup collapses diffs with the same values, like "timeout != <nil>", into
+// the first one, if DedupDiffs is true. Summary diffs are not collapsed.
+func (c *cmp) dedup() {
+	if !DedupDiffs || len(c.details) < 2 {
+		return
+	}
+	first := map[string]int{} // values => index in details
+	details := c.details[:0]
+	for _, d := range c.details {
+		if d.text != "" {
+			details = append(details, d)
+			continue
+		}
+		values := d.values()
+		if i, ok := first[values]; ok {
+			details[i].Duplicates++
+			continue
+		}
+		first[values] = len(details)
+		details = append(details, d)
+	}
+	c.details = details
+	c.diff = c.diff[:len(details)]
+	for i, d := range details {
+		c.diff[i] = d.String()
+	}
+}
+
+// duplicates returns the suffix of a diff with n duplicates, like
+// " (and 99 more)".
+func duplicates(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (and %d more)", n)
+}
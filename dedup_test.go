@@ -0,0 +1,48 @@
+package deep_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestDedupDiffs(t *testing.T) {
+	defer func(v bool, n int) { deep.DedupDiffs, deep.MaxDiff = v, n }(deep.DedupDiffs, deep.MaxDiff)
+	deep.MaxDiff = 100
+
+	type result struct {
+		ID  int
+		Err error
+	}
+	timeout := errors.New("timeout")
+	var a, b []result
+	for i := 0; i < 50; i++ {
+		a = append(a, result{ID: i, Err: timeout})
+		b = append(b, result{ID: i})
+	}
+	b[3].ID = 30
+	if diff := deep.Equal(a, b); len(diff) != 51 {
+		t.Errorf("expected 51 diffs, got %d", len(diff))
+	}
+
+	deep.DedupDiffs = true
+	diff := deep.Equal(a, b)
+	want := []string{
+		"slice[0].Err: *errors.errorString != <nil pointer> (and 49 more)",
+		"slice[3].ID: 3 != 30",
+	}
+	if len(diff) != len(want) {
+		t.Fatalf("got %d diffs, expected %d: %v", len(diff), len(want), diff)
+	}
+	for i := range want {
+		if diff[i] != want[i] {
+			t.Errorf("got %q, expected %q", diff[i], want[i])
+		}
+	}
+
+	d := deep.Compare(a, b)
+	if d.Len() != 2 || d.Differences[0].Duplicates != 49 || d.Differences[1].Duplicates != 0 {
+		t.Errorf("got %v", d.Strings())
+	}
+}
@@ -4,11 +4,22 @@
 package deep
 
 import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 )
 
 var (
@@ -16,22 +27,39 @@ var (
 	// to when comparing.
 	FloatPrecision = 10
 
-	// MaxDiff specifies the maximum number of differences to return.
+	// MaxDiff specifies the maximum number of differences to return, if
+	// greater than zero. If zero or less, there is no limit: every difference
+	// is returned, so comparing very different values can use a lot of memory
+	// and time, since comparing does not stop early.
 	MaxDiff = 10
 
 	// MaxDepth specifies the maximum levels of a struct to recurse into,
 	// if greater than zero. If zero, there is no limit.
 	MaxDepth = 0
 
-	// LogErrors causes errors to be logged to STDERR when true.
+	// LogErrors causes errors to be logged to STDERR when true. ErrorLogger
+	// takes precedence.
 	LogErrors = false
 
+	// ErrorLogger receives errors, like ErrTypeMismatch, with the path where
+	// they occurred, if not nil, instead of logging them to STDERR when
+	// LogErrors is true. It can be called concurrently if Parallelism is
+	// greater than 1.
+	ErrorLogger Logger
+
 	// CompareUnexportedFields causes unexported struct fields, like s in
 	// T{s int}, to be compared when true. This does not work for comparing
 	// error or Time types on unexported fields because methods on unexported
 	// fields cannot be called.
 	CompareUnexportedFields = false
 
+	// AllowUnsafe causes package unsafe to be used to read unexported struct
+	// fields when CompareUnexportedFields is true, so their methods can be
+	// called. For example, an unexported time.Time field is compared with
+	// time.Time.Equal instead of field by field, and an unexported error field
+	// is compared by its Error string.
+	AllowUnsafe = false
+
 	// CompareFunctions compares functions the same as reflect.DeepEqual:
 	// only two nil functions are equal. Every other combination is not equal.
 	// This is disabled by default because previous versions of this package
@@ -46,6 +74,270 @@ var (
 
 	// NilPointersAreZero causes a nil pointer to be equal to a zero value.
 	NilPointersAreZero = false
+
+	// EquateEmpty causes nil and empty values of the same type to be equal:
+	// it implies NilSlicesAreEmpty and NilMapsAreEmpty, and it makes channels
+	// comparable: a nil channel is equal to an empty channel, and two empty
+	// channels are equal. Non-empty channels are equal only if they are the
+	// same channel. Without EquateEmpty, channels are not compared. Nil
+	// functions are always equal; see CompareFunctions.
+	EquateEmpty = false
+
+	// CaseInsensitiveMapKeys causes string map keys that differ only by case,
+	// like "Foo" and "foo", to be the same key. If one map has more than one
+	// key with the same case-insensitive value, that is reported as a diff.
+	CaseInsensitiveMapKeys = false
+
+	// JSONTagNames causes struct fields to be named by their json tag in diff
+	// paths, like "user_name: a != b" instead of "UserName: a != b". Fields
+	// without a json tag name, or with the tag `json:"-"`, use the field name.
+	JSONTagNames = false
+
+	// EquateErrors causes errors to be compared like errors.Is and errors.As
+	// instead of comparing only their Error strings. Two errors are equal if
+	// either one is (wraps) the other, like fmt.Errorf("fetch user: %w", err)
+	// and err. Else, if either error wraps an error with the same type as the
+	// other, the Error strings of those two errors are compared.
+	EquateErrors = false
+
+	// UseEqualMethod causes the Equal method of a type, like time.Time.Equal,
+	// or its Compare or Cmp method, like big.Int.Cmp, to be called to check
+	// for equality. If false, these methods are not called and the type is
+	// compared like any other type (field by field).
+	UseEqualMethod = true
+
+	// IgnoreProtoInternals causes the internal fields of protobuf messages,
+	// like sizeCache and unknownFields, or XXX_sizecache and XXX_unrecognized
+	// in older generated code, to be ignored. A protobuf message is a struct
+	// type T where *T has a ProtoReflect or ProtoMessage method. When true,
+	// unexported fields of protobuf messages are ignored even if
+	// CompareUnexportedFields is true.
+	IgnoreProtoInternals = true
+
+	// MaxValueLength specifies the maximum length, in bytes, of a value in a
+	// diff, if greater than zero. Longer values are truncated and suffixed
+	// with the number of bytes removed, like "aaaa…(+9KB)", so one huge value
+	// does not make test logs unusable. If zero, there is no limit.
+	MaxValueLength = 0
+
+	// SummarizeRepeatedDiffs causes runs of at least this many consecutive
+	// slice or array elements that differ in the same way (same fields) to be
+	// reported as one diff, like "slice[5..10004]: 10000 elements differ",
+	// if greater than zero. This keeps one large, uniformly different slice
+	// from using all MaxDiff diffs. If zero, every element diff is reported.
+	SummarizeRepeatedDiffs = 0
+
+	// SampleElementDiffs causes only the diffs of the first this many differing
+	// slice or array elements to be reported, if greater than zero. The other
+	// differing elements are counted by the path that differs, relative to the
+	// element, and reported like "slice[*].Price: 4210 more elements differ".
+	// This takes precedence over SummarizeRepeatedDiffs. If zero, every
+	// element diff is reported.
+	SampleElementDiffs = 0
+
+	// CompareHandlesByName causes OS and runtime handles to be compared only by
+	// nil-ness and a stable name instead of their internals, which are
+	// nondeterministic and can be modified concurrently: *os.File by Name,
+	// *exec.Cmd by Path and Args, and *os.Process and pointer types that
+	// implement net.Conn or net.Listener only by nil-ness.
+	CompareHandlesByName = true
+
+	// EquateNumericKinds causes numbers of different types, like int(1),
+	// int64(1), uint8(1), and float64(1), to be compared by value instead of
+	// reported as a type mismatch like "int != float64". This is useful when
+	// one value was decoded from JSON into interface{}, which makes every
+	// number a float64. If either number is a float, both are compared as
+	// floats with FloatPrecision.
+	EquateNumericKinds = false
+
+	// ConvertibleTypes causes values of different types with the same
+	// underlying type, like type UserID string and string, to be compared by
+	// converting b to the type of a instead of reported as a type mismatch.
+	// The type difference is logged as ErrTypeConverted if LogErrors is true.
+	ConvertibleTypes = false
+
+	// IgnoreSyncTypes causes struct types in packages sync and sync/atomic,
+	// like sync.Mutex, sync.WaitGroup, and atomic.Int64, to be ignored (always
+	// equal). Their fields are unexported, so this matters only when
+	// CompareUnexportedFields is true, which would otherwise report diffs for
+	// lock state.
+	IgnoreSyncTypes = true
+
+	// SummarizeValueSize specifies the estimated size, in bytes, above which a
+	// value in a diff is summarized by its type, length, and hash, like
+	// "<[]uint8 len=10485760 sha256=1a2b3c4d>", instead of printed, if greater
+	// than zero. Unlike MaxValueLength, the size is estimated before the value
+	// is printed, so huge values are never printed. If zero, values are not
+	// summarized.
+	SummarizeValueSize = 0
+
+	// FormatValue formats values in diffs, if not nil. By default, values are
+	// formatted with %v. It is not called for descriptions printed in place of
+	// values, like "<nil pointer>". For example, to print values with Go
+	// syntax:
+	//
+	//   deep.FormatValue = func(v reflect.Value) string {
+	//       if v.CanInterface() {
+	//           return fmt.Sprintf("%#v", v.Interface())
+	//       }
+	//       return fmt.Sprintf("%#v", v)
+	//   }
+	FormatValue func(v reflect.Value) string
+
+	// UseStringer causes values in diffs to be printed with their String or
+	// Error method, if any, like "VALUE_ZERO != VALUE_ONE". If false, or if
+	// different values print the same, like two time.Time values with the
+	// same String but different locations, the methods are not called and
+	// the underlying values are printed, like "0 != 1".
+	UseStringer = true
+
+	// TimePrecision causes time.Time values to be truncated to a multiple of
+	// this duration, like time.Second, before comparing, if greater than zero.
+	// See time.Time.Truncate.
+	TimePrecision time.Duration = 0
+
+	// DurationPrecision causes time.Duration values to be truncated to a
+	// multiple of this duration, like time.Millisecond, before comparing, if
+	// greater than zero. It is separate from TimePrecision so that, for
+	// example, times can be compared to the second and durations exactly.
+	DurationPrecision time.Duration = 0
+
+	// EquateTimeLocation causes time.Time values to be converted to UTC before
+	// comparing, so the same instant in different locations is equal and diffs
+	// print both times in UTC. time.Time.Equal also ignores locations, but it
+	// cannot be called for unexported fields; EquateTimeLocation works for
+	// unexported time.Time fields, too, if CompareUnexportedFields is true.
+	EquateTimeLocation = false
+
+	// LockValues causes non-nil pointers to values that can be locked, like
+	// structs that embed a sync.Mutex or sync.RWMutex, to be locked while the
+	// values are compared, so comparing live state does not race with other
+	// goroutines that hold the lock while writing. If the type has RLock and
+	// RUnlock methods, they are used; else, if it implements sync.Locker,
+	// Lock and Unlock are used. If the type has a Snapshot method that takes
+	// no arguments and returns a value of a different type, the snapshots are
+	// compared instead, without locking; the method is expected to lock.
+	LockValues = false
+
+	// CopyValues causes Equal to compare deep copies of a and b made by Copy,
+	// which locks values only while copying them, instead of a and b. This
+	// reduces the time that live values are read, and the chance of reading
+	// torn state, when they are written concurrently.
+	CopyValues = false
+
+	// Parallelism specifies the number of goroutines used to compare the
+	// elements of slices, arrays, and maps with at least ParallelMinLen
+	// elements, if greater than 1. Diffs are reported in the same order as
+	// when comparing serially. It is ignored if SummarizeRepeatedDiffs,
+	// SampleElementDiffs, or LockValues is set. If zero, elements are
+	// compared serially.
+	Parallelism = 0
+
+	// ParallelMinLen is the minimum number of elements in a slice, array, or
+	// map to compare in parallel; see Parallelism.
+	ParallelMinLen = 10000
+
+	// MaxOps specifies the maximum number of values to compare, if greater
+	// than zero. When it is reached, Equal stops comparing, logs ErrMaxOps,
+	// and reports a diff at the path where it stopped, like
+	// "Nodes.slice[9].Next: <stopped after MaxOps=1000000> != <stopped after
+	// MaxOps=1000000>", so pathological inputs, like huge or deeply nested
+	// graphs, fail fast with a useful message instead of timing out.
+	MaxOps = 0
+
+	// MultiValueOrder causes the values of a key in http.Header,
+	// textproto.MIMEHeader, and url.Values to be compared in order. By
+	// default, order is ignored, so "Accept: a, b" equals "Accept: b, a".
+	// Header keys are always canonicalized, like "content-type" to
+	// "Content-Type", and diffs are printed like "Header[Content-Type]: ...".
+	MultiValueOrder = false
+
+	// StrictURLs causes url.URL values to be compared by their exact string
+	// form. By default, they are compared by a normalized form: the scheme and
+	// host are lower case, escaped unreserved characters in the path and
+	// fragment are decoded, and query parameters are sorted, so
+	// "http://Example.com/%7Ea?y=2&x=1" equals "http://example.com/~a?x=1&y=2".
+	// Escaped reserved characters are kept, so "/a%2Fb" does not equal "/a/b".
+	// Diffs print the URLs with passwords redacted.
+	StrictURLs = false
+
+	// BytesFormat causes []byte values that differ to be reported as one diff
+	// with the offset of the first difference and both values printed in this
+	// format: "string" (quoted), "hex", or "base64", like
+	// `Data: bytes differ at offset 2: "abc" != "abd"`. If empty, the
+	// elements that differ are reported like other slices, like
+	// "Data.slice[2]: 99 != 100".
+	BytesFormat = ""
+
+	// StringContext causes long strings that differ to be reported with the
+	// offset of the first difference and only this many bytes of context
+	// before and after it, if greater than zero, like
+	// `Body: string differs at offset 1042: "...abcXdef..." != "...abcYdef..."`.
+	// A string is long if it is longer than 2*StringContext+1 bytes. If zero,
+	// strings are printed in full.
+	StringContext = 0
+
+	// FieldFilter is called before comparing every struct field, if not nil,
+	// with the field and the type of the struct. The field is compared only if
+	// it returns true. This can skip fields by rule, like fields named
+	// "Internal*" or tagged deprecated, without tagging each type. Fields
+	// ignored by other options, like unexported fields, are not passed to it.
+	FieldFilter func(field reflect.StructField, t reflect.Type) bool
+
+	// PathSeparator is printed between the elements of paths in diffs, like
+	// "." in "Address.City: foo != bar".
+	PathSeparator = "."
+
+	// PathRoot is printed before every path in diffs, if not empty, like
+	// "want" in "want.Address.City: foo != bar" and "want: foo != bar".
+	PathRoot = ""
+
+	// CompactIndexes causes slice, array, and map elements to be printed in
+	// diffs without the kind and separator, like "Items[2].Name" and
+	// "Labels[foo]" instead of "Items.slice[2].Name" and "Labels.map[foo]".
+	// Paths in Difference.Path and path patterns, like for IgnorePaths, are
+	// not affected.
+	CompactIndexes = false
+
+	// InterfaceFieldTypesOnly causes struct fields of interface types, like
+	// Strategy in T{Strategy Runner}, to be compared only by the dynamic types
+	// of their values, like "Strategy: *pkg.Fast != *pkg.Slow", not by the
+	// values. This checks that both values chose the same implementation
+	// without comparing its internals. To do this for one field, use the tag
+	// `deep:"type"`. Other interface values, like in map[string]interface{},
+	// are not affected.
+	InterfaceFieldTypesOnly = false
+
+	// MissingKeysAreZero causes a map that does not have a key to be equal to
+	// a map that has the key with an empty value, like omitempty in JSON and
+	// protobuf: false, 0, "", a nil pointer or interface, an empty slice or
+	// map, or a zero struct. An interface holding an empty value, like 0 in
+	// map[string]interface{}, is empty, too. This keeps data that was
+	// round-tripped through such a format from having "<does not have key>"
+	// diffs for empty entries.
+	MissingKeysAreZero = false
+
+	// IgnoreZeroFields causes struct fields that are the zero value in a, the
+	// expected value, to be ignored, so a sparse fixture like T{Name: "foo"}
+	// is equal to any T with Name "foo". Only fields are ignored: zero slice
+	// elements and map values are compared.
+	IgnoreZeroFields = false
+
+	// DedupDiffs causes diffs with the same values at different paths, like
+	// the same error at hundreds of paths, to be collapsed into the first one
+	// with the number of other paths, like
+	// "Items.slice[0].Err: timeout != <nil> (and 99 more)". MaxDiff counts
+	// diffs before they are collapsed.
+	DedupDiffs = false
+
+	// MaxDiffPerPath specifies the maximum number of differences to return
+	// below each top-level field, key, or element, like "Items" in
+	// "Items.slice[2].Name", if greater than zero. When it is reached, one
+	// diff like "Items: <stopped after MaxDiffPerPath=5>" is added and other
+	// differences below the path are not reported, so one very different
+	// slice does not use all MaxDiff differences and hide the rest. If zero,
+	// there is no limit except MaxDiff.
+	MaxDiffPerPath = 0
 )
 
 var (
@@ -55,8 +347,20 @@ var (
 	// ErrTypeMismatch is logged when Equal passed two different types of values.
 	ErrTypeMismatch = errors.New("variables are different reflect.Type")
 
+	// ErrTypeConverted is logged when ConvertibleTypes causes Equal to convert
+	// a value to compare two different types.
+	ErrTypeConverted = errors.New("converted different reflect.Type")
+
+	// ErrMaxOps is logged when MaxOps is reached.
+	ErrMaxOps = errors.New("compared MaxOps values")
+
 	// ErrNotHandled is logged when a primitive Go kind is not handled.
 	ErrNotHandled = errors.New("cannot compare the reflect.Kind")
+
+	// ErrUnknownKind is logged when a reflect.Kind is not known, like a kind
+	// added by a new Go release, and no func is registered for it; see
+	// RegisterKind.
+	ErrUnknownKind = errors.New("unknown reflect.Kind")
 )
 
 const (
@@ -70,16 +374,293 @@ const (
 	// like []T where T is a struct, are undefined because Equal does not
 	// recurse into the slice value when this flag is enabled.
 	FLAG_IGNORE_SLICE_ORDER
+
+	// FLAG_GENERATED_STRUCTS causes Equal to ignore the artifacts of code
+	// generated for Avro and Thrift messages, so decoded messages can be
+	// compared to literals: fields with the prefix "XXX_" and unexported fields
+	// are ignored, a nil pointer is equal to a pointer to a zero value (an
+	// unset optional field), and only the active branch of union wrapper
+	// structs is compared. A union wrapper is a struct with an integer
+	// UnionType field whose value is the index of the active branch field,
+	// as generated by gogen-avro.
+	FLAG_GENERATED_STRUCTS
 )
 
+// elementMatcher is the flag returned by ElementMatcher.
+type elementMatcher struct {
+	elemType reflect.Type
+	fn       reflect.Value
+}
+
+// ElementMatcher returns a flag for Equal that compares slices of T in any
+// order. fn must be a func(T, T) bool that returns true if two elements are
+// the same element, for example if they have the same ID, but not necessarily
+// equal. Each element in a is paired with the first unpaired element in b
+// that fn matches, and then the pair is compared like any other value, so
+// differences are reported per field. The diff path uses the index of the
+// element in a, or the index in b for elements in b that were not matched.
+// Elements that were not matched are reported as "<no match>".
+//
+// ElementMatcher panics if fn is not a func(T, T) bool.
+func ElementMatcher(fn interface{}) interface{} {
+	v := reflect.ValueOf(fn)
+	t := v.Type()
+	if t.Kind() != reflect.Func || t.NumIn() != 2 || t.In(0) != t.In(1) ||
+		t.NumOut() != 1 || t.Out(0).Kind() != reflect.Bool {
+		panic(fmt.Sprintf("deep: ElementMatcher: %s is not a func(T, T) bool", t))
+	}
+	return elementMatcher{elemType: t.In(0), fn: v}
+}
+
+// redactTypes is the flag returned by RedactTypes.
+type redactTypes []reflect.Type
+
+// RedactTypes returns a flag for Equal that redacts values with the same type
+// as any of vs, like a Password or Token type, in diffs. Differences are still
+// reported, but both values are printed as "<redacted>", like
+// "Password: <redacted> != <redacted>", so diffs can be pasted into bug
+// trackers and CI logs without leaking secrets. To redact a single struct
+// field, use the tag `deep:"redact"`. To redact values by path, use Redact.
+func RedactTypes(vs ...interface{}) interface{} {
+	r := make(redactTypes, len(vs))
+	for i := range vs {
+		r[i] = reflect.TypeOf(vs[i])
+	}
+	return r
+}
+
+// redactPaths is the flag returned by Redact.
+type redactPaths []string
+
+// Redact returns a flag for Equal that redacts values at paths matching any of
+// patterns in diffs, like RedactTypes. A pattern is a diff path, like
+// "Password" or "User.Token", where "*" matches any part of one path segment,
+// like "*.Token" or "map[*]". Values below a matching path, like
+// "Secret.Key" for pattern "Secret", are redacted, too.
+func Redact(patterns ...string) interface{} {
+	return redactPaths(patterns)
+}
+
+// hashPaths is the flag returned by HashPaths.
+type hashPaths struct {
+	salt     []byte
+	patterns []string
+}
+
+// HashPaths returns a flag for Equal that prints values at paths matching any
+// of patterns (see Redact) as a salted hash in diffs, like
+// "Password: hash 9f2c1a7e != hash 03bd55c2", so it's clear that a value
+// changed without exposing the values. The hash is the first 4 bytes of the
+// HMAC-SHA256 of the printed value with key salt. Equal values have the same
+// hash, so use a secret salt if values are guessable.
+func HashPaths(salt []byte, patterns ...string) interface{} {
+	return hashPaths{salt: salt, patterns: patterns}
+}
+
+// ignorePaths is the flag returned by IgnorePaths.
+type ignorePaths []string
+
+// IgnorePaths returns a flag for Equal that ignores values at paths matching
+// any of patterns, and values below them. A pattern is a diff path where "*"
+// matches any part of one path segment, like "*.CreatedAt" or
+// "Items.slice[*].ID"; see Redact. See SuggestIgnorePaths to find paths that
+// always differ.
+func IgnorePaths(patterns ...string) interface{} {
+	return ignorePaths(patterns)
+}
+
+// onCompare is the flag returned by OnCompare.
+type onCompare func(path []string, a, b reflect.Value, equal bool)
+
+// OnCompare returns a flag for Equal that calls fn after comparing every pair
+// of values, including pointers and the values they point to, which have the
+// same path, and values below the top level, like struct fields and slice
+// elements. The path is like a diff path split by ".", and equal is true if
+// no diffs were found for the values. Ignored values are not compared. fn can
+// be used to find which fields were compared, report progress, or log. If
+// Parallelism is greater than 1, fn can be called concurrently.
+func OnCompare(fn func(path []string, a, b reflect.Value, equal bool)) interface{} {
+	return onCompare(fn)
+}
+
+// matchPath returns true if path matches pattern; see Redact.
+func matchPath(pattern string, path []string) bool {
+	segments := strings.Split(pattern, ".")
+	if len(segments) != len(path) {
+		return false
+	}
+	for i := range segments {
+		if !matchSegment(segments[i], path[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchSegment returns true if s matches pattern, where "*" matches any
+// string.
+func matchSegment(pattern, s string) bool {
+	star := strings.IndexByte(pattern, '*')
+	if star < 0 {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, pattern[:star]) {
+		return false
+	}
+	for i := star; i <= len(s); i++ {
+		if matchSegment(pattern[star+1:], s[i:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// mapValueIdentity is the flag returned by MapValueIdentity.
+type mapValueIdentity struct {
+	elemType    reflect.Type
+	fn          reflect.Value
+	compareKeys bool
+}
+
+// MapValueIdentity returns a flag for Equal that pairs the entries of maps with
+// values of type V by the identity of their values instead of by their keys.
+// This is useful when keys are synthetic, like random UUIDs, but the values
+// have a natural identity. fn must be a func(V) T, where T is comparable, that
+// returns the identity of a value, for example its ID field. Paired values are
+// compared like any other value, and the diff path uses the key in a. If
+// compareKeys is true, paired entries with different keys are reported as
+// "map[k1].(key): k1 != k2" separately from value differences. Values that
+// were not paired are reported as "<no match>", and values with the same
+// identity as another value in the same map, in key order, are reported like
+// "map[k2]: duplicate identity: <no match> != {...}".
+//
+// MapValueIdentity panics if fn is not a func(V) T or T is not comparable.
+func MapValueIdentity(fn interface{}, compareKeys bool) interface{} {
+	v := reflect.ValueOf(fn)
+	t := v.Type()
+	if t.Kind() != reflect.Func || t.NumIn() != 1 || t.NumOut() != 1 || !t.Out(0).Comparable() {
+		panic(fmt.Sprintf("deep: MapValueIdentity: %s is not a func(V) T with comparable T", t))
+	}
+	return mapValueIdentity{elemType: t.In(0), fn: v, compareKeys: compareKeys}
+}
+
+// kindSchema is the flag returned by KindSchema.
+type kindSchema map[string]reflect.Kind
+
+// KindSchema returns a flag for Equal that validates the kinds of values at
+// the given paths while comparing, which is useful for loosely-typed values
+// like config and API payloads decoded into interface{}. Paths are diff paths,
+// like "map[port]" or "Config.map[port]", and kinds are the kinds of the
+// values after dereferencing pointers and interfaces. If a value has a
+// different kind, it is reported separately from value diffs, like
+// "map[port].(b kind): string != int", and its values are not compared. Nil
+// values are not validated.
+func KindSchema(schema map[string]reflect.Kind) interface{} {
+	return kindSchema(schema)
+}
+
 type cmp struct {
-	diff        []string
-	buff        []string
-	floatFormat string
-	flag        map[byte]bool
+	diff           []string
+	details        []Difference // diff details, like the path
+	buff           []string
+	floatFormat    string
+	floatPrecision int // of floatFormat
+	flag           map[byte]bool
+	matchers       map[reflect.Type]reflect.Value
+	identities     map[reflect.Type]mapValueIdentity
+	jsonTypes      map[reflect.Type]bool
+	redactTypes    map[reflect.Type]bool
+	redactPaths    []string
+	ignorePaths    []string
+	pathMatchers   []pathMatcher
+	redact         int // redact values in diffs if > 0
+	hashPaths      hashPaths
+	hash           int           // hash values in diffs if > 0
+	precision      time.Duration // from tag `deep:"precision=d"` if > 0
+	kinds          kindSchema
+	locked         map[uintptr]bool // pointers locked by LockValues
+	ops            *int64           // values compared, shared by forks
+	ctx            context.Context  // from EqualContext, or nil
+	ctxErr         error            // ctx.Err() when comparing stopped
+	calls          int              // calls to equals, to check ctx periodically
+	visiting       map[visit]bool   // pointer pairs being compared, to detect cycles
+	onCompare      onCompare
+	serial         bool // ignore Parallelism
+	maxDiff        int  // MaxDiff, unless overridden
+	wantGot        bool // from EqualWantGot
+	warnings       []Warning
+	pathDiffs      map[string]int // diffs by top-level path; see MaxDiffPerPath
+	stopped        []string       // top-level paths that reached MaxDiffPerPath
+	forked         bool           // a fork; MaxDiffPerPath is applied when merged
 }
 
-var errorType = reflect.TypeOf((*error)(nil)).Elem()
+// visit is a pair of pointers, maps, or slices of type t being compared.
+type visit struct {
+	a, b uintptr
+	t    reflect.Type
+}
+
+var (
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// methods are the indexes of methods that Equal calls, or -1 if the type
+// does not have the method. The indexes are used with reflect.Value.Method
+// because Value.MethodByName is slow and it prevents the linker from
+// removing unused methods.
+type methods struct {
+	equal    int
+	error    int
+	compare  int // Compare or Cmp
+	snapshot int
+
+	protoMessage bool // has ProtoReflect or ProtoMessage method
+}
+
+var methodCache sync.Map // reflect.Type => methods
+
+// methodsOf returns the cached methods of type t, resolving them on first use.
+func methodsOf(t reflect.Type) methods {
+	if m, ok := methodCache.Load(t); ok {
+		return m.(methods)
+	}
+	m := methods{equal: -1, error: -1, compare: -1, snapshot: -1}
+	if f, ok := t.MethodByName("Equal"); ok && isMethodOf(f, t, reflect.Bool) {
+		m.equal = f.Index
+	}
+	if f, ok := t.MethodByName("Error"); ok {
+		m.error = f.Index
+	}
+	if f, ok := t.MethodByName("Compare"); ok && isMethodOf(f, t, reflect.Int) {
+		m.compare = f.Index
+	} else if f, ok := t.MethodByName("Cmp"); ok && isMethodOf(f, t, reflect.Int) {
+		m.compare = f.Index
+	}
+	if f, ok := t.MethodByName("Snapshot"); ok {
+		m.snapshot = f.Index
+	}
+	if _, ok := t.MethodByName("ProtoReflect"); ok {
+		m.protoMessage = true
+	} else if _, ok := t.MethodByName("ProtoMessage"); ok {
+		m.protoMessage = true
+	}
+	methodCache.Store(t, m)
+	return m
+}
+
+// isMethodOf returns true if method f of type t is func(t) out, like Equal of
+// time.Time, which is func(time.Time) bool. It is false for a method promoted
+// from an embedded field, like Equal of struct{ time.Time }, which takes a
+// time.Time, not the struct.
+func isMethodOf(f reflect.Method, t reflect.Type, out reflect.Kind) bool {
+	if t.Kind() == reflect.Interface {
+		return false // f.Type has no receiver
+	}
+	return f.Type.NumIn() == 2 && f.Type.In(1) == t &&
+		f.Type.NumOut() == 1 && f.Type.Out(0).Kind() == out
+}
 
 // Equal compares variables a and b, recursing into their structure up to
 // MaxDepth levels deep (if greater than zero), and returns a list of differences,
@@ -87,60 +668,309 @@ var errorType = reflect.TypeOf((*error)(nil)).Elem()
 // also returned.
 //
 // If a type has an Equal method, like time.Equal, it is called to check for
-// equality.
+// equality, unless UseEqualMethod is false. Likewise, if a type has a Compare
+// or Cmp method that returns an int, like big.Int.Cmp, the values are equal
+// if it returns zero.
+//
+// Cyclic values, like a linked list whose last node points to the first, are
+// compared until a pair of pointers, maps, or slices is reached again.
+//
+// Map keys are compared in order of their printed values, like "map[foo]", so
+// diffs are deterministic. Keys that are not equal to themselves, like NaN,
+// cannot be looked up, so they are paired in that order.
 //
 // When comparing a struct, if a field has the tag `deep:"-"` then it will be
-// ignored.
+// ignored. If a []byte field has the tag `deep:"decompress=gzip"`, its values
+// are decompressed before comparing; see RegisterDecompressor. If a string or
+// []byte field has the tag `deep:"json"`, its values are compared as JSON
+// documents; see EqualJSON. If a field has the tag `deep:"redact"`, its values
+// are printed as "<redacted>" in diffs; see RedactTypes. If a time.Time or
+// time.Duration field has the tag `deep:"precision=1s"`, its values are
+// truncated to that precision instead of TimePrecision or DurationPrecision.
+// If a slice field has the tag `deep:"sorted"`, copies of its values are
+// sorted before comparing, so order is ignored; see RegisterLess. If a slice
+// field has the tag `deep:"set"`, its values are compared as sets, so order
+// and duplicates are ignored, and elements that differ are reported like
+// "Tags: x != <missing element>" and "Tags: <extra element> != y". If an
+// interface field has the tag `deep:"type"`, only the dynamic types of its
+// values are compared; see InterfaceFieldTypesOnly. If a pointer field has
+// the tag `deep:"noderef"`, its values are compared as addresses, so they are
+// equal only if they point to the same value, and they are printed like
+// "<pointer 0xc000012345>" in diffs. If a field has the tag `deep:"optional"`,
+// it is ignored if either value is the zero value. If a string field has the
+// tag `deep:"semver"`, its values are compared as semantic versions; see
+// Semver.
 func Equal(a, b interface{}, flags ...interface{}) []string {
-	aVal := reflect.ValueOf(a)
-	bVal := reflect.ValueOf(b)
-	c := &cmp{
-		diff:        []string{},
-		buff:        []string{},
-		floatFormat: fmt.Sprintf("%%.%df", FloatPrecision),
-		flag:        map[byte]bool{},
+	c := newCmp(flags)
+	defer c.release()
+	c.compareValues(a, b)
+	return c.result()
+}
+
+// EqualWantGot is like Equal(want, got, flags...) but diffs are printed with
+// labels, and string values are quoted, like `Name: want "foo", got "bar"`,
+// instead of "Name: foo != bar".
+func EqualWantGot(want, got interface{}, flags ...interface{}) []string {
+	c := newCmp(flags)
+	defer c.release()
+	c.wantGot = true
+	c.compareValues(want, got)
+	return c.result()
+}
+
+// EqualValues is like Equal but compares reflect.Value a and b directly,
+// without converting them to interface{}, which keeps addressability, so, for
+// example, addressable values read from unexported fields can be compared
+// like exported fields if AllowUnsafe and CompareUnexportedFields are true.
+// An invalid (zero) Value is like a nil interface{}.
+func EqualValues(a, b reflect.Value, flags ...interface{}) []string {
+	if AllowUnsafe && CompareUnexportedFields {
+		a, b = exportField(a), exportField(b)
+	}
+	c := newCmp(flags)
+	defer c.release()
+	c.equals(a, b, 0)
+	return c.result()
+}
+
+// EqualContext is like Equal but stops comparing when ctx is done, checking it
+// periodically, and returns the differences found so far and ctx.Err(). If
+// comparing finishes, the error is nil even if ctx is done.
+func EqualContext(ctx context.Context, a, b interface{}, flags ...interface{}) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c := newCmp(flags)
+	defer c.release()
+	c.ctx = ctx
+	c.compareValues(a, b)
+	return c.result(), c.ctxErr
+}
+
+// maxInt is the maximum int value.
+const maxInt = int(^uint(0) >> 1)
+
+// maxDiff returns MaxDiff, or maxInt if there is no limit.
+func maxDiff() int {
+	if MaxDiff <= 0 {
+		return maxInt
+	}
+	return MaxDiff
+}
+
+// ctxCheckInterval is the number of values compared between checks of the
+// context passed to EqualContext.
+const ctxCheckInterval = 64
+
+// newCmp returns a new cmp with the given flags.
+func newCmp(flags []interface{}) *cmp {
+	c := cmpPool.Get().(*cmp)
+	c.maxDiff = maxDiff()
+	if c.floatPrecision != FloatPrecision || c.floatFormat == "" {
+		c.floatPrecision = FloatPrecision
+		c.floatFormat = fmt.Sprintf("%%.%df", FloatPrecision)
 	}
 	for i := range flags {
-		c.flag[flags[i].(byte)] = true
+		switch f := flags[i].(type) {
+		case elementMatcher:
+			c.matchers[f.elemType] = f.fn
+		case mapValueIdentity:
+			c.identities[f.elemType] = f
+		case jsonType:
+			c.jsonTypes[f.t] = true
+		case redactTypes:
+			for _, t := range f {
+				c.redactTypes[t] = true
+			}
+		case kindSchema:
+			c.kinds = f
+		case redactPaths:
+			c.redactPaths = append(c.redactPaths, f...)
+		case hashPaths:
+			c.hashPaths = f
+		case ignorePaths:
+			c.ignorePaths = append(c.ignorePaths, f...)
+		case onCompare:
+			c.onCompare = f
+		case pathMatcher:
+			c.pathMatchers = append(c.pathMatchers, f)
+		default:
+			c.flag[f.(byte)] = true
+		}
 	}
-	if a == nil && b == nil {
-		return nil
-	} else if a == nil && b != nil {
-		c.saveDiff("<nil pointer>", b)
-	} else if a != nil && b == nil {
-		c.saveDiff(a, "<nil pointer>")
+	return c
+}
+
+// cmpPool reuses cmps, and their buffers and maps, across calls to Equal.
+var cmpPool = sync.Pool{
+	New: func() interface{} {
+		return &cmp{
+			ops:         new(int64),
+			diff:        []string{},
+			buff:        []string{},
+			flag:        map[byte]bool{},
+			matchers:    map[reflect.Type]reflect.Value{},
+			identities:  map[reflect.Type]mapValueIdentity{},
+			jsonTypes:   map[reflect.Type]bool{},
+			redactTypes: map[reflect.Type]bool{},
+			visiting:    make(map[visit]bool, 16),
+		}
+	},
+}
+
+// release resets c and returns it to cmpPool. The diffs and details are not
+// reused because they are returned to the caller. c must not be used after
+// it is released.
+func (c *cmp) release() {
+	for k := range c.flag {
+		delete(c.flag, k)
 	}
-	if len(c.diff) > 0 {
-		return c.diff
+	for k := range c.matchers {
+		delete(c.matchers, k)
+	}
+	for k := range c.identities {
+		delete(c.identities, k)
+	}
+	for k := range c.jsonTypes {
+		delete(c.jsonTypes, k)
+	}
+	for k := range c.redactTypes {
+		delete(c.redactTypes, k)
 	}
+	for k := range c.visiting {
+		delete(c.visiting, k) // if compare panicked
+	}
+	*c.ops = 0
+	*c = cmp{
+		ops:            c.ops,
+		diff:           []string{},
+		buff:           c.buff[:0],
+		floatFormat:    c.floatFormat,
+		floatPrecision: c.floatPrecision,
+		flag:           c.flag,
+		matchers:       c.matchers,
+		identities:     c.identities,
+		jsonTypes:      c.jsonTypes,
+		redactTypes:    c.redactTypes,
+		visiting:       c.visiting,
+	}
+	cmpPool.Put(c)
+}
 
-	c.equals(aVal, bVal, 0)
+// compare compares a and b and returns the diffs, or nil if there are none.
+func (c *cmp) compare(a, b interface{}) []string {
+	c.compareValues(a, b)
 	if len(c.diff) > 0 {
 		return c.diff // diffs
 	}
 	return nil // no diffs
 }
 
+// result returns the diffs, collapsed if DedupDiffs is true, or nil if there
+// are none.
+func (c *cmp) result() []string {
+	c.dedup()
+	if len(c.diff) > 0 {
+		return c.diff
+	}
+	return nil
+}
+
+// compareValues compares top-level values a and b, adding to any diffs
+// already saved.
+func (c *cmp) compareValues(a, b interface{}) {
+	if CopyValues {
+		a, b = Copy(a), Copy(b)
+	}
+	switch {
+	case a == nil && b == nil:
+	case a == nil:
+		c.saveDiff(marker("<nil pointer>"), b)
+	case b == nil:
+		c.saveDiff(a, marker("<nil pointer>"))
+	default:
+		c.equals(reflect.ValueOf(a), reflect.ValueOf(b), 0)
+	}
+}
+
 func (c *cmp) equals(a, b reflect.Value, level int) {
 	if MaxDepth > 0 && level > MaxDepth {
-		logError(ErrMaxRecursion)
+		c.logError(ErrMaxRecursion)
+		return
+	}
+
+	if c.ctx != nil {
+		if c.ctxErr != nil {
+			return
+		}
+		if c.calls++; c.calls%ctxCheckInterval == 0 {
+			if c.ctxErr = c.ctx.Err(); c.ctxErr != nil {
+				return
+			}
+		}
+	}
+
+	if MaxOps > 0 {
+		if n := atomic.AddInt64(c.ops, 1); n > int64(MaxOps) {
+			if n == int64(MaxOps)+1 {
+				c.logError(ErrMaxOps)
+				stopped := marker(fmt.Sprintf("<stopped after MaxOps=%d>", MaxOps))
+				c.saveDiff(stopped, stopped)
+			}
+			return
+		}
+	}
+
+	if c.ignorePaths != nil && c.matchPaths(c.ignorePaths) {
 		return
 	}
 
+	if c.onCompare != nil {
+		start := len(c.diff)
+		defer func(a, b reflect.Value) {
+			c.onCompare(append([]string(nil), c.buff...), a, b, len(c.diff) == start)
+		}(a, b)
+	}
+
 	// Check if one value is nil, e.g. T{x: *X} and T.x is nil
 	if !a.IsValid() || !b.IsValid() {
 		if a.IsValid() && !b.IsValid() {
-			c.saveDiff(a.Type(), "<nil pointer>")
+			c.saveDiff(a.Type(), marker("<nil pointer>"))
 		} else if !a.IsValid() && b.IsValid() {
-			c.saveDiff("<nil pointer>", b.Type())
+			c.saveDiff(marker("<nil pointer>"), b.Type())
 		}
 		return
 	}
 
-	// If different types, they can't be equal
+	if c.pathMatchers != nil && c.equalsMatcher(a, b) {
+		return
+	}
+
+	if c.kinds != nil && !c.validKinds(a, b) {
+		return
+	}
+
+	if len(transformers) > 0 {
+		a, b = transform(a), transform(b)
+	}
+
+	// If different types, they can't be equal, except errors with EquateErrors
+	// and numbers with EquateNumericKinds
 	aType := a.Type()
 	bType := b.Type()
-	if aType != bType {
+	if aType != bType && EquateNumericKinds && isNumber(a) && isNumber(b) {
+		c.equalsNumber(a, b)
+		return
+	}
+	if aType != bType && ConvertibleTypes && aType.Kind() == bType.Kind() && bType.ConvertibleTo(aType) {
+		c.logError(fmt.Errorf("%w: %s: %s != %s", ErrTypeConverted, strings.Join(c.buff, "."), aType, bType))
+		b = b.Convert(aType)
+		bType = aType
+	}
+	// With EquateErrors, different error types are compared below, unless they
+	// cannot be interfaced to call Error, like in an unexported field
+	if aType != bType && !(EquateErrors && aType.Implements(errorType) && bType.Implements(errorType) &&
+		a.CanInterface() && b.CanInterface()) {
 		// Built-in types don't have a name, so don't report [3]int != [2]int as " != "
 		if aType.Name() == "" || aType.Name() != bType.Name() {
 			c.saveDiff(aType, bType)
@@ -151,12 +981,70 @@ func (c *cmp) equals(a, b reflect.Value, level int) {
 			// https://github.com/go-test/deep/issues/39
 			aFullType := aType.PkgPath() + "." + aType.Name()
 			bFullType := bType.PkgPath() + "." + bType.Name()
-			c.saveDiff(aFullType, bFullType)
+			n := len(c.details)
+			c.saveDiff(marker(aFullType), marker(bFullType))
+			if len(c.details) > n {
+				c.details[n].category = typeMismatch
+			}
 		}
-		logError(ErrTypeMismatch)
+		c.logError(ErrTypeMismatch)
 		return
 	}
 
+	if IgnoreSyncTypes && aType.Kind() == reflect.Struct &&
+		(aType.PkgPath() == "sync" || aType.PkgPath() == "sync/atomic") {
+		return
+	}
+
+	if CompareHandlesByName && aType.Kind() == reflect.Ptr && isHandle(aType) {
+		c.equalsHandle(a, b)
+		return
+	}
+
+	if c.redactTypes[aType] || c.matchPaths(c.redactPaths) {
+		c.redact++
+		defer func() { c.redact-- }()
+	}
+	if c.matchPaths(c.hashPaths.patterns) {
+		c.hash++
+		defer func() { c.hash-- }()
+	}
+
+	if c.jsonTypes[aType] {
+		c.equalsJSON(a, b, level)
+		return
+	}
+
+	if aType == urlType && a.CanInterface() && b.CanInterface() {
+		c.equalsURL(a, b)
+		return
+	}
+
+	// net/netip types, like netip.Addr and netip.Prefix, have only unexported
+	// fields, and not every version has a Compare method for every type, so
+	// compare their canonical strings, like "10.0.0.0/8".
+	if aType.PkgPath() == "net/netip" && aType.Kind() == reflect.Struct &&
+		a.CanInterface() && b.CanInterface() {
+		if aStr, ok := a.Interface().(fmt.Stringer); ok {
+			bStr := b.Interface().(fmt.Stringer)
+			if aStr.String() != bStr.String() {
+				c.saveDiff(aStr.String(), bStr.String())
+			}
+			return
+		}
+	}
+
+	if aType.Kind() == reflect.Map {
+		if name := multiValueMap(aType); name != "" {
+			c.equalsMultiValues(a, b, name)
+			return
+		}
+	}
+
+	if aType == timeType || aType == durationType {
+		a, b = c.normalizeTime(a), c.normalizeTime(b)
+	}
+
 	// Primitive https://golang.org/pkg/reflect/#Kind
 	aKind := a.Kind()
 	bKind := b.Kind()
@@ -177,14 +1065,67 @@ func (c *cmp) equals(a, b reflect.Value, level int) {
 	if (aType.Implements(errorType) && bType.Implements(errorType)) &&
 		((!aElem || !a.IsNil()) && (!bElem || !b.IsNil())) &&
 		(a.CanInterface() && b.CanInterface()) {
-		aString := a.MethodByName("Error").Call(nil)[0].String()
-		bString := b.MethodByName("Error").Call(nil)[0].String()
-		if aString != bString {
+		if EquateErrors {
+			a, b = equateErrors(a, b)
+			if !a.IsValid() {
+				return // errors.Is
+			}
+		}
+		aOut, aPanic := callMethod("Error", a.Method(methodsOf(a.Type()).error))
+		bOut, bPanic := callMethod("Error", b.Method(methodsOf(b.Type()).error))
+		if aPanic != "" || bPanic != "" {
+			var aval, bval interface{} = aPanic, bPanic
+			if aPanic == "" {
+				aval = aOut[0].String()
+			} else if bPanic == "" {
+				bval = bOut[0].String()
+			}
+			c.saveDiff(aval, bval)
+			return
+		}
+		if aString, bString := aOut[0].String(), bOut[0].String(); aString != bString {
 			c.saveDiff(aString, bString)
 		}
 		return
 	}
 
+	// Types with a Compare(T) int or Cmp(T) int method, like netip.Addr and
+	// *big.Int. This must be done before dereferencing because the method can
+	// have a pointer receiver, like big.Int.
+	if i := methodsOf(aType).compare; UseEqualMethod && i >= 0 && aKind != reflect.Interface &&
+		(!aElem || !a.IsNil()) && (!bElem || !b.IsNil()) &&
+		a.CanInterface() && b.CanInterface() && aType == bType {
+		out, panicked := callMethod("Compare", a.Method(i), b)
+		if panicked != "" {
+			c.saveDiff(panicked, panicked)
+		} else if out[0].Int() != 0 {
+			c.saveDiff(a, b)
+		}
+		return
+	}
+
+	// Detect cycles, like a linked list whose last node points to the first,
+	// by the pair of pointers being compared, not each pointer, because a
+	// node shared by different parts of a graph can be compared to different
+	// nodes. If the pair is already being compared, it is equal so far.
+	if (aKind == reflect.Ptr || aKind == reflect.Map || aKind == reflect.Slice) &&
+		bKind == aKind && !a.IsNil() && !b.IsNil() {
+		v := visit{a: a.Pointer(), b: b.Pointer(), t: aType}
+		if c.visiting[v] {
+			return
+		}
+		c.visiting[v] = true
+		defer delete(c.visiting, v)
+	}
+
+	if LockValues && aKind == reflect.Ptr && !a.IsNil() && !b.IsNil() &&
+		a.CanInterface() && b.CanInterface() {
+		if c.equalsSnapshot(a, b, level) {
+			return
+		}
+		defer c.lock(a, b)()
+	}
+
 	// Dereference pointers and interface{}
 	if aElem || bElem {
 		if aElem {
@@ -193,16 +1134,24 @@ func (c *cmp) equals(a, b reflect.Value, level int) {
 		if bElem {
 			b = b.Elem()
 		}
-		if aElem && NilPointersAreZero && !a.IsValid() && b.IsValid() {
+		nilZero := NilPointersAreZero || c.flag[FLAG_GENERATED_STRUCTS]
+		if aElem && nilZero && !a.IsValid() && b.IsValid() {
 			a = reflect.Zero(b.Type())
 		}
-		if bElem && NilPointersAreZero && !b.IsValid() && a.IsValid() {
+		if bElem && nilZero && !b.IsValid() && a.IsValid() {
 			b = reflect.Zero(a.Type())
 		}
 		c.equals(a, b, level+1)
 		return
 	}
 
+	if equal, ok := kindFuncs[aKind]; ok {
+		if !equal(a, b) {
+			c.saveDiff(a, b)
+		}
+		return
+	}
+
 	switch aKind {
 
 	/////////////////////////////////////////////////////////////////////
@@ -223,48 +1172,121 @@ func (c *cmp) equals(a, b reflect.Value, level int) {
 
 		// Types with an Equal() method, like time.Time, only if struct field
 		// is exported (CanInterface)
-		if eqFunc := a.MethodByName("Equal"); eqFunc.IsValid() && eqFunc.CanInterface() {
-			// Handle https://github.com/go-test/deep/issues/15:
-			// Don't call T.Equal if the method is from an embedded struct, like:
-			//   type Foo struct { time.Time }
-			// First, we'll encounter Equal(Ttime, time.Time) but if we pass b
-			// as the 2nd arg we'll panic: "Call using pkg.Foo as type time.Time"
-			// As far as I can tell, there's no way to see that the method is from
-			// time.Time not Foo. So we check the type of the 1st (0) arg and skip
-			// unless it's b type. Later, we'll encounter the time.Time anonymous/
-			// embedded field and then we'll have Equal(time.Time, time.Time).
-			funcType := eqFunc.Type()
-			if funcType.NumIn() == 1 && funcType.In(0) == bType {
-				retVals := eqFunc.Call([]reflect.Value{b})
-				if !retVals[0].Bool() {
-					c.saveDiff(a, b)
-				}
-				return
+		//
+		// Handle https://github.com/go-test/deep/issues/15:
+		// Don't call T.Equal if the method is from an embedded struct, like:
+		//   type Foo struct { time.Time }
+		// First, we'll encounter Equal(Ttime, time.Time) but if we pass b
+		// as the 2nd arg we'll panic: "Call using pkg.Foo as type time.Time"
+		// As far as I can tell, there's no way to see that the method is from
+		// time.Time not Foo. So methodsOf checks the type of the arg and skips
+		// it unless it's the receiver type (see isMethodOf). Later, we'll
+		// encounter the time.Time anonymous/embedded field and then we'll have
+		// Equal(time.Time, time.Time).
+		if i := methodsOf(aType).equal; UseEqualMethod && i >= 0 && a.Method(i).CanInterface() && aType == bType {
+			retVals, panicked := callMethod("Equal", a.Method(i), b)
+			if panicked != "" {
+				c.saveDiff(panicked, panicked)
+			} else if !retVals[0].Bool() {
+				c.saveDiff(a, b)
 			}
+			return
+		}
+
+		isProto := IgnoreProtoInternals && methodsOf(reflect.PtrTo(aType)).protoMessage
+		generated := c.flag[FLAG_GENERATED_STRUCTS]
+
+		if generated && c.equalsUnion(a, b, level) {
+			return // union wrapper, only active branch compared
+		}
+
+		useUnsafe := AllowUnsafe && CompareUnexportedFields
+		if useUnsafe {
+			a, b = addressable(a), addressable(b)
 		}
 
 		for i := 0; i < a.NumField(); i++ {
-			if aType.Field(i).PkgPath != "" && !CompareUnexportedFields {
+			field := aType.Field(i)
+			if field.PkgPath != "" && !CompareUnexportedFields {
 				continue // skip unexported field, e.g. s in type T struct {s string}
 			}
 
-			if aType.Field(i).Tag.Get("deep") == "-" {
+			if isProto && (field.PkgPath != "" || strings.HasPrefix(field.Name, "XXX_")) {
+				continue // skip protobuf internal field
+			}
+
+			if generated && (field.PkgPath != "" || strings.HasPrefix(field.Name, "XXX_")) {
+				continue // skip generated internal field
+			}
+
+			if field.Tag.Get("deep") == "-" {
 				continue // field wants to be ignored
 			}
 
-			c.push(aType.Field(i).Name) // push field name to buff
+			if FieldFilter != nil && !FieldFilter(field, aType) {
+				continue // field filtered out
+			}
+			opts := tagOptions(field)
+
+			c.push(fieldName(field)) // push field name to buff
 
 			// Get the Value for each field, e.g. FirstName has Type = string,
 			// Kind = reflect.String.
 			af := a.Field(i)
 			bf := b.Field(i)
+			if field.PkgPath != "" && (useUnsafe || (EquateTimeLocation && field.Type == timeType)) {
+				a, b = addressable(a), addressable(b)
+				af, bf = exportField(a.Field(i)), exportField(b.Field(i))
+			}
+
+			if IgnoreZeroFields && af.IsZero() {
+				c.pop()
+				continue // field not expected
+			}
+			if _, ok := opts["optional"]; ok && (af.IsZero() || bf.IsZero()) {
+				c.pop()
+				continue // optional field not set
+			}
+
+			if _, ok := opts["redact"]; ok {
+				c.redact++
+			}
+			precision := c.precision
+			if p, ok := opts["precision"]; ok {
+				if d, err := time.ParseDuration(p); err != nil {
+					c.logError(fmt.Errorf("%s: invalid precision: %w", field.Name, err))
+				} else {
+					c.precision = d
+				}
+			}
 
 			// Recurse to compare the field values
-			c.equals(af, bf, level+1)
+			if name, ok := opts["decompress"]; ok {
+				c.equalsDecompressed(af, bf, name, level+1)
+			} else if _, ok := opts["json"]; ok {
+				c.equalsJSON(af, bf, level+1)
+			} else if _, ok := opts["sorted"]; ok {
+				c.equalsSorted(af, bf, level+1)
+			} else if _, ok := opts["set"]; ok {
+				c.equalsSet(af, bf, level+1)
+			} else if _, ok := opts["type"]; ok || (InterfaceFieldTypesOnly && field.Type.Kind() == reflect.Interface) {
+				c.equalsDynamicType(af, bf, level+1)
+			} else if _, ok := opts["noderef"]; ok {
+				c.equalsPointer(af, bf, level+1)
+			} else if _, ok := opts["semver"]; ok {
+				c.equalsSemver(af, bf, level+1)
+			} else {
+				c.equals(af, bf, level+1)
+			}
+
+			if _, ok := opts["redact"]; ok {
+				c.redact--
+			}
+			c.precision = precision
 
 			c.pop() // pop field name from buff
 
-			if len(c.diff) >= MaxDiff {
+			if c.full() {
 				break
 			}
 		}
@@ -285,19 +1307,19 @@ func (c *cmp) equals(a, b reflect.Value, level int) {
 		*/
 
 		if a.IsNil() || b.IsNil() {
-			if NilMapsAreEmpty {
+			if NilMapsAreEmpty || EquateEmpty {
 				if a.IsNil() && b.Len() != 0 {
-					c.saveDiff("<nil map>", b)
+					c.saveDiff(marker("<nil map>"), b)
 					return
 				} else if a.Len() != 0 && b.IsNil() {
-					c.saveDiff(a, "<nil map>")
+					c.saveDiff(a, marker("<nil map>"))
 					return
 				}
 			} else {
 				if a.IsNil() && !b.IsNil() {
-					c.saveDiff("<nil map>", b)
+					c.saveDiff(marker("<nil map>"), b)
 				} else if !a.IsNil() && b.IsNil() {
-					c.saveDiff(a, "<nil map>")
+					c.saveDiff(a, marker("<nil map>"))
 				}
 			}
 			return
@@ -307,61 +1329,125 @@ func (c *cmp) equals(a, b reflect.Value, level int) {
 			return
 		}
 
-		for _, key := range a.MapKeys() {
-			c.push(fmt.Sprintf("map[%v]", key))
+		if id, ok := c.identities[aType.Elem()]; ok && a.CanInterface() && b.CanInterface() {
+			c.cmpMapsByIdentity(a, b, id, level)
+			return
+		}
+
+		if CaseInsensitiveMapKeys && aType.Key().Kind() == reflect.String {
+			c.cmpMapsCaseInsensitive(a, b, level)
+			return
+		}
 
-			aVal := a.MapIndex(key)
-			bVal := b.MapIndex(key)
+		// Keys are compared in order of their names so diffs are reported
+		// deterministically. Keys not equal to themselves, like NaN, cannot
+		// be looked up, so they are paired in order.
+		aEntries := mapEntries(a, nil)
+		bEntries := mapEntries(b, func(key reflect.Value) bool {
+			return unequalKey(key) || !a.MapIndex(key).IsValid()
+		})
+		var bUnequal []mapEntry
+		for _, e := range bEntries {
+			if unequalKey(e.key) {
+				bUnequal = append(bUnequal, e)
+			}
+		}
+		elem := func(c *cmp, e mapEntry, bVal reflect.Value) {
+			c.push(e.name)
 			if bVal.IsValid() {
-				c.equals(aVal, bVal, level+1)
-			} else {
-				c.saveDiff(aVal, "<does not have key>")
+				c.equals(e.val, bVal, level+1)
+			} else if !MissingKeysAreZero || !isEmptyValue(e.val) {
+				c.saveDiff(e.val, marker("<does not have key>"))
 			}
-
 			c.pop()
-
-			if len(c.diff) >= MaxDiff {
+		}
+		nUnequal := 0
+		if len(bUnequal) == 0 && c.parallel(len(aEntries)) {
+			c.compareParallel(len(aEntries), func(c *cmp, i int) {
+				var bVal reflect.Value
+				if !unequalKey(aEntries[i].key) {
+					bVal = b.MapIndex(aEntries[i].key)
+				}
+				elem(c, aEntries[i], bVal)
+			})
+			if c.full() {
 				return
 			}
+		} else {
+			for _, e := range aEntries {
+				var bVal reflect.Value
+				if unequalKey(e.key) {
+					if nUnequal < len(bUnequal) {
+						bVal = bUnequal[nUnequal].val
+					}
+					nUnequal++
+				} else {
+					bVal = b.MapIndex(e.key)
+				}
+				elem(c, e, bVal)
+				if c.full() {
+					return
+				}
+			}
 		}
 
-		for _, key := range b.MapKeys() {
-			if aVal := a.MapIndex(key); aVal.IsValid() {
+		for _, e := range bEntries {
+			if unequalKey(e.key) {
+				if nUnequal > 0 {
+					nUnequal--
+					continue
+				}
+			}
+			if MissingKeysAreZero && isEmptyValue(e.val) {
 				continue
 			}
 
-			c.push(fmt.Sprintf("map[%v]", key))
-			c.saveDiff("<does not have key>", b.MapIndex(key))
+			c.push(e.name)
+			c.saveDiff(marker("<does not have key>"), e.val)
 			c.pop()
-			if len(c.diff) >= MaxDiff {
+			if c.full() {
 				return
 			}
 		}
 	case reflect.Array:
 		n := a.Len()
-		for i := 0; i < n; i++ {
+		elem := func(c *cmp, i int) {
 			c.push(fmt.Sprintf("array[%d]", i))
 			c.equals(a.Index(i), b.Index(i), level+1)
 			c.pop()
-			if len(c.diff) >= MaxDiff {
+		}
+		if c.parallel(n) {
+			c.compareParallel(n, elem)
+			return
+		}
+		run := &elementRun{kind: "array"}
+		sample := &elementSample{kind: "array"}
+		for i := 0; i < n; i++ {
+			start := len(c.diff)
+			elem(c, i)
+			run.add(c, i, start)
+			sample.add(c, start)
+			if c.full() {
 				break
 			}
 		}
+		run.end(c)
+		sample.end(c)
 	case reflect.Slice:
-		if NilSlicesAreEmpty {
+		if NilSlicesAreEmpty || EquateEmpty {
 			if a.IsNil() && b.Len() != 0 {
-				c.saveDiff("<nil slice>", b)
+				c.saveDiff(marker("<nil slice>"), b)
 				return
 			} else if a.Len() != 0 && b.IsNil() {
-				c.saveDiff(a, "<nil slice>")
+				c.saveDiff(a, marker("<nil slice>"))
 				return
 			}
 		} else {
 			if a.IsNil() && !b.IsNil() {
-				c.saveDiff("<nil slice>", b)
+				c.saveDiff(marker("<nil slice>"), b)
 				return
 			} else if !a.IsNil() && b.IsNil() {
-				c.saveDiff(a, "<nil slice>")
+				c.saveDiff(a, marker("<nil slice>"))
 				return
 			}
 		}
@@ -378,7 +1464,19 @@ func (c *cmp) equals(a, b reflect.Value, level int) {
 			return
 		}
 
-		if c.flag[FLAG_IGNORE_SLICE_ORDER] {
+		if aType.Elem().Kind() == reflect.Uint8 {
+			if bytes.Equal(a.Bytes(), b.Bytes()) {
+				return
+			}
+			if BytesFormat != "" {
+				c.equalsBytes(a.Bytes(), b.Bytes())
+				return
+			}
+		}
+
+		if match, ok := c.matchers[aType.Elem()]; ok && a.CanInterface() && b.CanInterface() {
+			c.cmpMatchedElements(a, b, match, level)
+		} else if c.flag[FLAG_IGNORE_SLICE_ORDER] {
 			// Compare slices by value and value count; ignore order.
 			// Value equality is impliclity established by the maps:
 			// any value v1 will hash to the same map value if it's equal
@@ -401,20 +1499,34 @@ func (c *cmp) equals(a, b reflect.Value, level int) {
 			if bLen > aLen {
 				n = bLen
 			}
-			for i := 0; i < n; i++ {
+			elem := func(c *cmp, i int) {
 				c.push(fmt.Sprintf("slice[%d]", i))
 				if i < aLen && i < bLen {
 					c.equals(a.Index(i), b.Index(i), level+1)
 				} else if i < aLen {
-					c.saveDiff(a.Index(i), "<no value>")
+					c.saveDiff(a.Index(i), marker("<no value>"))
 				} else {
-					c.saveDiff("<no value>", b.Index(i))
+					c.saveDiff(marker("<no value>"), b.Index(i))
 				}
 				c.pop()
-				if len(c.diff) >= MaxDiff {
+			}
+			if c.parallel(n) {
+				c.compareParallel(n, elem)
+				return
+			}
+			run := &elementRun{kind: "slice"}
+			sample := &elementSample{kind: "slice"}
+			for i := 0; i < n; i++ {
+				start := len(c.diff)
+				elem(c, i)
+				run.add(c, i, start)
+				sample.add(c, start)
+				if c.full() {
 					break
 				}
 			}
+			run.end(c)
+			sample.end(c)
 		}
 
 	/////////////////////////////////////////////////////////////////////
@@ -447,13 +1559,29 @@ func (c *cmp) equals(a, b reflect.Value, level int) {
 			c.saveDiff(a.Uint(), b.Uint())
 		}
 	case reflect.String:
-		if a.String() != b.String() {
-			c.saveDiff(a.String(), b.String())
+		if aStr, bStr := a.String(), b.String(); aStr != bStr {
+			if n := 2*StringContext + 1; StringContext > 0 && (len(aStr) > n || len(bStr) > n) {
+				c.saveLongStrings(aStr, bStr)
+			} else {
+				c.saveDiff(aStr, bStr)
+			}
+		}
+	case reflect.Chan:
+		if !EquateEmpty {
+			c.logError(ErrNotHandled)
+			return
 		}
+		if a.Pointer() == b.Pointer() || (a.Len() == 0 && b.Len() == 0) {
+			return
+		}
+		c.saveDiff(marker(chanString(a)), marker(chanString(b)))
 	case reflect.Func:
+		if !CompareFunctions && (!a.IsNil() || !b.IsNil()) {
+			c.logError(ErrNotHandled)
+		}
 		if CompareFunctions {
 			if !a.IsNil() || !b.IsNil() {
-				aVal, bVal := "nil func", "nil func"
+				aVal, bVal := marker("nil func"), marker("nil func")
 				if !a.IsNil() {
 					aVal = "func"
 				}
@@ -464,8 +1592,220 @@ func (c *cmp) equals(a, b reflect.Value, level int) {
 			}
 		}
 	default:
-		logError(ErrNotHandled)
+		// A kind added after this package, or a kind whose func was
+		// unregistered, so it cannot be compared. Report it rather than
+		// ignore it; see RegisterKind.
+		c.logError(ErrUnknownKind)
+		cannot := marker(fmt.Sprintf("<cannot compare %s>", aKind))
+		c.saveDiff(cannot, cannot)
+	}
+}
+
+// matchPaths returns true if the current path matches any of patterns.
+func (c *cmp) matchPaths(patterns []string) bool {
+	for _, p := range patterns {
+		if matchPath(p, c.buff) {
+			return true
+		}
 	}
+	return false
+}
+
+// normalizeTime returns time.Time or time.Duration v truncated to
+// TimePrecision or DurationPrecision, or the precision in the tag
+// `deep:"precision=d"`, and time.Time v in UTC if EquateTimeLocation is true.
+func (c *cmp) normalizeTime(v reflect.Value) reflect.Value {
+	p := c.precision
+	if p <= 0 && v.Type() == timeType {
+		p = TimePrecision
+	} else if p <= 0 {
+		p = DurationPrecision
+	}
+	utc := EquateTimeLocation && v.Type() == timeType
+	if (p <= 0 && !utc) || !v.CanInterface() {
+		return v
+	}
+	switch t := v.Interface().(type) {
+	case time.Time:
+		if utc {
+			t = t.UTC()
+		}
+		if p > 0 {
+			t = t.Truncate(p)
+		}
+		return reflect.ValueOf(t)
+	case time.Duration:
+		return reflect.ValueOf(t.Truncate(p))
+	}
+	return v
+}
+
+// callMethod calls method fn, named name, with args. If the method panics, it
+// recovers and returns the panic like "<Equal panicked: boom>".
+func callMethod(name string, fn reflect.Value, args ...reflect.Value) (out []reflect.Value, panicked marker) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicked = marker(fmt.Sprintf("<%s panicked: %v>", name, r))
+		}
+	}()
+	return fn.Call(args), ""
+}
+
+// validKinds returns false if a or b has a different kind than the kind in
+// KindSchema for the current path, after reporting the difference.
+func (c *cmp) validKinds(a, b reflect.Value) bool {
+	aKind, bKind := a.Kind(), b.Kind()
+	if aKind == reflect.Ptr || aKind == reflect.Interface || bKind == reflect.Ptr || bKind == reflect.Interface {
+		return true // validated after dereferencing
+	}
+	want, ok := c.kinds[strings.Join(c.buff, ".")]
+	if !ok {
+		return true
+	}
+	valid := true
+	for _, v := range []struct {
+		name string
+		kind reflect.Kind
+	}{{"(a kind)", aKind}, {"(b kind)", bKind}} {
+		if v.kind != want {
+			c.push(v.name)
+			c.saveDiff(marker(v.kind.String()), marker(want.String()))
+			c.pop()
+			valid = false
+		}
+	}
+	return valid
+}
+
+// isNumber returns true if v is an integer or float.
+func isNumber(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// equalsNumber compares numbers a and b of different types by value.
+func (c *cmp) equalsNumber(a, b reflect.Value) {
+	aSigned, aFloat := numberKind(a)
+	bSigned, bFloat := numberKind(b)
+	var equal bool
+	switch {
+	case aFloat || bFloat:
+		equal = fmt.Sprintf(c.floatFormat, toFloat(a)) == fmt.Sprintf(c.floatFormat, toFloat(b))
+	case aSigned && bSigned:
+		equal = a.Int() == b.Int()
+	case !aSigned && !bSigned:
+		equal = a.Uint() == b.Uint()
+	case aSigned:
+		equal = a.Int() >= 0 && uint64(a.Int()) == b.Uint()
+	default:
+		equal = b.Int() >= 0 && a.Uint() == uint64(b.Int())
+	}
+	if !equal {
+		c.saveDiff(a, b)
+	}
+}
+
+// numberKind returns whether number v is a signed integer or a float.
+func numberKind(v reflect.Value) (signed, float bool) {
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return false, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true, false
+	}
+	return false, false
+}
+
+// toFloat returns number v as a float64.
+func toFloat(v reflect.Value) float64 {
+	switch signed, float := numberKind(v); {
+	case float:
+		return v.Float()
+	case signed:
+		return float64(v.Int())
+	}
+	return float64(v.Uint())
+}
+
+// equalsUnion compares a and b if they are union wrapper structs (see
+// FLAG_GENERATED_STRUCTS) and returns true, else it returns false.
+func (c *cmp) equalsUnion(a, b reflect.Value, level int) bool {
+	f, ok := a.Type().FieldByName("UnionType")
+	if !ok || len(f.Index) != 1 {
+		return false
+	}
+	var ai, bi int64
+	switch f.Type.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		ai, bi = a.Field(f.Index[0]).Int(), b.Field(f.Index[0]).Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		ai, bi = int64(a.Field(f.Index[0]).Uint()), int64(b.Field(f.Index[0]).Uint())
+	default:
+		return false
+	}
+	if ai < 0 || ai >= int64(a.NumField()) || int(ai) == f.Index[0] {
+		return false
+	}
+	if ai != bi {
+		c.push(f.Name)
+		c.saveDiff(a.Field(f.Index[0]), b.Field(f.Index[0]))
+		c.pop()
+		return true
+	}
+	branch := a.Type().Field(int(ai))
+	c.push(fieldName(branch))
+	c.equals(a.Field(int(ai)), b.Field(int(ai)), level+1)
+	c.pop()
+	return true
+}
+
+// tagOptions returns the comma-separated options in the deep tag of struct
+// field f, like `deep:"decompress=gzip"`, as option name => value. Options
+// without a value, like "redact", have an empty value.
+func tagOptions(f reflect.StructField) map[string]string {
+	tag := f.Tag.Get("deep")
+	if tag == "" {
+		return nil
+	}
+	opts := map[string]string{}
+	for _, opt := range strings.Split(tag, ",") {
+		kv := strings.SplitN(opt, "=", 2)
+		if len(kv) == 2 {
+			opts[kv[0]] = kv[1]
+		} else {
+			opts[kv[0]] = ""
+		}
+	}
+	return opts
+}
+
+// fieldName returns the name of struct field f in diff paths.
+func fieldName(f reflect.StructField) string {
+	if JSONTagNames {
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return f.Name
+}
+
+// chanString returns a channel as it is printed in diffs, like "chan(len=2)".
+func chanString(v reflect.Value) string {
+	if v.IsNil() {
+		return "<nil chan>"
+	}
+	return fmt.Sprintf("chan(len=%d)", v.Len())
+}
+
+// full returns true if the maximum number of diffs has been saved; see MaxDiff.
+func (c *cmp) full() bool {
+	return len(c.diff) >= c.maxDiff
 }
 
 func (c *cmp) push(name string) {
@@ -476,15 +1816,147 @@ func (c *cmp) pop() {
 	if len(c.buff) > 0 {
 		c.buff = c.buff[0 : len(c.buff)-1]
 	}
+	if len(c.buff) == 0 {
+		c.saveStopped()
+	}
 }
 
 func (c *cmp) saveDiff(aval, bval interface{}) {
-	if len(c.buff) > 0 {
-		varName := strings.Join(c.buff, ".")
-		c.diff = append(c.diff, fmt.Sprintf("%s: %v != %v", varName, aval, bval))
+	c.saveNote("", aval, bval)
+}
+
+// saveNote is like saveDiff but prints note before the values, like
+// "Data: bytes differ at offset 2: a != b".
+func (c *cmp) saveNote(note string, aval, bval interface{}) {
+	if c.ignorePaths != nil && c.matchPaths(c.ignorePaths) {
+		return // like a missing map key at an ignored path
+	}
+	if MaxDiffPerPath > 0 && !c.forked && len(c.buff) > 0 && !c.countPath(c.buff[0]) {
+		return
+	}
+	category := diffCategory(aval, bval)
+	if c.redact > 0 {
+		aval, bval = marker("<redacted>"), marker("<redacted>")
+	} else if c.hash > 0 {
+		aval, bval = marker(c.hashValue(aval)), marker(c.hashValue(bval))
+	}
+	as := formatValue(aval, !UseStringer)
+	bs := formatValue(bval, !UseStringer)
+	if as == bs && UseStringer {
+		as, bs = formatValue(aval, true), formatValue(bval, true) // same String
+	}
+	if c.wantGot {
+		as, bs = quoteString(aval, as), quoteString(bval, bs)
+	}
+	d := Difference{Path: append([]string(nil), c.buff...), A: as, B: bs, note: note, wantGot: c.wantGot, category: category, a: aval, b: bval}
+	c.details = append(c.details, d)
+	c.diff = append(c.diff, d.String())
+}
+
+// countPath counts a diff below top-level path top and returns true if it is
+// within MaxDiffPerPath. When the limit is first exceeded, top is saved to
+// report it once its values are compared; see saveStopped.
+func (c *cmp) countPath(top string) bool {
+	if c.pathDiffs == nil {
+		c.pathDiffs = map[string]int{}
+	}
+	c.pathDiffs[top]++
+	n := c.pathDiffs[top]
+	if n == MaxDiffPerPath+1 {
+		c.stopped = append(c.stopped, top)
+	}
+	return n <= MaxDiffPerPath
+}
+
+// saveStopped saves one diff for each top-level path that reached
+// MaxDiffPerPath. It is called when the path is popped, not when the limit is
+// reached, so the diff is not among the diffs of a slice element being
+// summarized; see elementRun and elementSample.
+func (c *cmp) saveStopped() {
+	for _, top := range c.stopped {
+		stopped := marker(fmt.Sprintf("<stopped after MaxDiffPerPath=%d>", MaxDiffPerPath))
+		d := Difference{Path: []string{top}, A: string(stopped), B: string(stopped), a: stopped, b: stopped}
+		c.details = append(c.details, d)
+		c.diff = append(c.diff, d.String())
+	}
+	c.stopped = nil
+}
+
+// quoted is a string value that is already quoted, like a window of a long
+// string; see StringContext.
+type quoted string
+
+// quoteString returns s, the formatted value v, quoted if v is a string.
+func quoteString(v interface{}, s string) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(s)
+	case reflect.Value:
+		if v.Kind() == reflect.String {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
+
+// hashValue returns v printed as a salted hash; see HashPaths.
+func (c *cmp) hashValue(v interface{}) string {
+	h := hmac.New(sha256.New, c.hashPaths.salt)
+	fmt.Fprintf(h, "%v", v)
+	return "hash " + hex.EncodeToString(h.Sum(nil)[:4])
+}
+
+// marker is printed in a diff in place of a value, like "<nil pointer>". It is
+// not formatted like a value.
+type marker string
+
+// formatValue returns v as it is printed in a diff, truncated to MaxValueLength.
+// If raw is true, String and Error methods are not called; see formatRaw.
+func formatValue(v interface{}, raw bool) string {
+	switch v := v.(type) {
+	case marker:
+		return string(v)
+	case reflect.Type:
+		return v.String()
+	}
+	if s, ok := summarizeValue(v); ok {
+		return s
+	}
+	var s string
+	if FormatValue != nil {
+		rv, ok := v.(reflect.Value)
+		if !ok {
+			rv = reflect.ValueOf(v)
+		}
+		s = FormatValue(rv)
+	} else if raw {
+		rv, ok := v.(reflect.Value)
+		if !ok {
+			rv = reflect.ValueOf(v)
+		}
+		s = formatRaw(rv, 0)
 	} else {
-		c.diff = append(c.diff, fmt.Sprintf("%v != %v", aval, bval))
+		s = fmt.Sprintf("%v", v)
 	}
+	if MaxValueLength <= 0 || len(s) <= MaxValueLength {
+		return s
+	}
+	n := MaxValueLength
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n-- // don't split a multi-byte rune
+	}
+	return s[:n] + "…(+" + formatSize(len(s)-n) + ")"
+}
+
+// formatSize returns n bytes in human-readable form, like 512B, 9KB, or 2MB.
+func formatSize(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%dMB", n>>20)
+	case n >= 1<<10:
+		return fmt.Sprintf("%dKB", n>>10)
+	}
+	return fmt.Sprintf("%dB", n)
 }
 
 func (c *cmp) cmpMapValueCounts(a, b reflect.Value, am, bm map[interface{}]int, a2b bool) {
@@ -493,11 +1965,15 @@ func (c *cmp) cmpMapValueCounts(a, b reflect.Value, am, bm map[interface{}]int,
 		bCount, _ := bm[v]
 
 		if aCount != bCount {
-			c.push(fmt.Sprintf("(unordered) slice[]=%v: value count", v))
+			var name interface{} = v
+			if c.redact > 0 {
+				name = "<redacted>"
+			}
+			c.push(fmt.Sprintf("(unordered) slice[]=%v: value count", name))
 			if a2b {
-				c.saveDiff(fmt.Sprintf("%d", aCount), fmt.Sprintf("%d", bCount))
+				c.saveDiff(marker(fmt.Sprint(aCount)), marker(fmt.Sprint(bCount)))
 			} else {
-				c.saveDiff(fmt.Sprintf("%d", bCount), fmt.Sprintf("%d", aCount))
+				c.saveDiff(marker(fmt.Sprint(bCount)), marker(fmt.Sprint(aCount)))
 			}
 			c.pop()
 		}
@@ -506,8 +1982,324 @@ func (c *cmp) cmpMapValueCounts(a, b reflect.Value, am, bm map[interface{}]int,
 	}
 }
 
-func logError(err error) {
-	if LogErrors {
+// cmpMatchedElements compares slices a and b by pairing each element in a with
+// the first unpaired element in b for which match returns true, then comparing
+// each pair. Elements without a pair are reported as "<no match>".
+func (c *cmp) cmpMatchedElements(a, b, match reflect.Value, level int) {
+	paired := make([]bool, b.Len())
+	for i := 0; i < a.Len(); i++ {
+		j := 0
+		for ; j < b.Len(); j++ {
+			if !paired[j] && match.Call([]reflect.Value{a.Index(i), b.Index(j)})[0].Bool() {
+				break
+			}
+		}
+		c.push(fmt.Sprintf("slice[%d]", i))
+		if j < b.Len() {
+			paired[j] = true
+			c.equals(a.Index(i), b.Index(j), level+1)
+		} else {
+			c.saveDiff(a.Index(i), marker("<no match>"))
+		}
+		c.pop()
+		if c.full() {
+			return
+		}
+	}
+	for j := range paired {
+		if paired[j] {
+			continue
+		}
+		c.push(fmt.Sprintf("slice[%d]", j))
+		c.saveDiff(marker("<no match>"), b.Index(j))
+		c.pop()
+		if c.full() {
+			return
+		}
+	}
+}
+
+// cmpMapsByIdentity compares maps a and b by pairing their entries by the
+// identity of their values, as returned by id.fn, rather than by their keys.
+func (c *cmp) cmpMapsByIdentity(a, b reflect.Value, id mapValueIdentity, level int) {
+	identity := func(v reflect.Value) interface{} {
+		return id.fn.Call([]reflect.Value{v})[0].Interface()
+	}
+	// Pair entries in key order, like mapEntries, so the same diffs are
+	// reported. Entries with the identity of a previous entry in the same map
+	// are reported as duplicates, not paired.
+	aEntries := mapEntries(a, nil)
+	bEntries := mapEntries(b, nil)
+	bIndex := make(map[interface{}]int, len(bEntries)) // identity => first entry
+	bDup := make([]bool, len(bEntries))
+	for j, e := range bEntries {
+		bID := identity(e.val)
+		if _, ok := bIndex[bID]; ok {
+			bDup[j] = true
+		} else {
+			bIndex[bID] = j
+		}
+	}
+	paired := make([]bool, len(bEntries))
+	aSeen := map[interface{}]bool{}
+	for _, e := range aEntries {
+		c.push(e.name)
+		aID := identity(e.val)
+		j, ok := bIndex[aID]
+		switch {
+		case aSeen[aID]:
+			c.saveNote("duplicate identity", e.val, marker("<no match>"))
+		case ok:
+			paired[j] = true
+			bKey := bEntries[j].key
+			if id.compareKeys && e.key.Interface() != bKey.Interface() {
+				c.push("(key)")
+				c.saveDiff(e.key, bKey)
+				c.pop()
+			}
+			if !c.full() {
+				c.equals(e.val, bEntries[j].val, level+1)
+			}
+		default:
+			c.saveDiff(e.val, marker("<no match>"))
+		}
+		aSeen[aID] = true
+		c.pop()
+		if c.full() {
+			return
+		}
+	}
+	for j, e := range bEntries {
+		if paired[j] {
+			continue
+		}
+		c.push(e.name)
+		if bDup[j] {
+			c.saveNote("duplicate identity", marker("<no match>"), e.val)
+		} else {
+			c.saveDiff(marker("<no match>"), e.val)
+		}
+		c.pop()
+		if c.full() {
+			return
+		}
+	}
+}
+
+// cmpMapsCaseInsensitive compares maps a and b with string keys, treating keys
+// that differ only by case as the same key.
+func (c *cmp) cmpMapsCaseInsensitive(a, b reflect.Value, level int) {
+	// Group keys by their lower case value, sorted so diffs are reported in the
+	// same order, like mapEntries
+	folded := []string{}
+	aKeys := map[string][]reflect.Value{}
+	bKeys := map[string][]reflect.Value{}
+	group := func(m reflect.Value, keys map[string][]reflect.Value) {
+		for _, key := range m.MapKeys() {
+			k := strings.ToLower(key.String())
+			if _, ok := aKeys[k]; !ok {
+				if _, ok := bKeys[k]; !ok {
+					folded = append(folded, k)
+				}
+			}
+			keys[k] = append(keys[k], key)
+		}
+	}
+	group(a, aKeys)
+	group(b, bKeys)
+	sort.Strings(folded)
+	for _, keys := range []map[string][]reflect.Value{aKeys, bKeys} {
+		for _, k := range keys {
+			sort.Slice(k, func(i, j int) bool { return k[i].String() < k[j].String() })
+		}
+	}
+
+	keyDesc := func(keys []reflect.Value) string {
+		if len(keys) == 0 {
+			return "<does not have key>"
+		}
+		s := make([]string, len(keys))
+		for i := range keys {
+			s[i] = keys[i].String()
+		}
+		sort.Strings(s)
+		if len(s) == 1 {
+			return "<key " + s[0] + ">"
+		}
+		return "<duplicate keys " + strings.Join(s, ", ") + ">"
+	}
+
+	for _, k := range folded {
+		ak, bk := aKeys[k], bKeys[k]
+		if len(ak) > 0 {
+			c.push(mapKeyName(ak[0]))
+		} else {
+			c.push(mapKeyName(bk[0]))
+		}
+		switch {
+		case len(ak) > 1 || len(bk) > 1:
+			c.saveDiff(marker(keyDesc(ak)), marker(keyDesc(bk)))
+		case len(bk) == 0:
+			c.saveDiff(a.MapIndex(ak[0]), marker("<does not have key>"))
+		case len(ak) == 0:
+			c.saveDiff(marker("<does not have key>"), b.MapIndex(bk[0]))
+		default:
+			c.equals(a.MapIndex(ak[0]), b.MapIndex(bk[0]), level+1)
+		}
+		c.pop()
+		if c.full() {
+			return
+		}
+	}
+}
+
+// equateErrors returns invalid values if error a is b or b is a according to
+// errors.Is. Else, it returns the error wrapped by a that has the same type as
+// b, or the error wrapped by b that has the same type as a, or a and b if
+// neither error wraps the other type.
+func equateErrors(a, b reflect.Value) (reflect.Value, reflect.Value) {
+	aErr := a.Interface().(error)
+	bErr := b.Interface().(error)
+	if errors.Is(aErr, bErr) || errors.Is(bErr, aErr) {
+		return reflect.Value{}, reflect.Value{}
+	}
+	if err := findErrorType(aErr, reflect.TypeOf(bErr)); err != nil {
+		return reflect.ValueOf(err), reflect.ValueOf(bErr)
+	}
+	if err := findErrorType(bErr, reflect.TypeOf(aErr)); err != nil {
+		return reflect.ValueOf(aErr), reflect.ValueOf(err)
+	}
+	return a, b
+}
+
+// findErrorType returns the first error in the tree of err, like errors.As,
+// that has type t, or nil if there is none.
+func findErrorType(err error, t reflect.Type) error {
+	if err == nil {
+		return nil
+	}
+	if reflect.TypeOf(err) == t {
+		return err
+	}
+	switch x := err.(type) {
+	case interface{ Unwrap() error }:
+		return findErrorType(x.Unwrap(), t)
+	case interface{ Unwrap() []error }:
+		for _, err := range x.Unwrap() {
+			if err := findErrorType(err, t); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// equalsDynamicType compares interface values a and b only by the types of the
+// values they hold; see InterfaceFieldTypesOnly. Other kinds are compared
+// normally.
+func (c *cmp) equalsDynamicType(a, b reflect.Value, level int) {
+	if a.Kind() != reflect.Interface {
+		c.logError(ErrNotHandled)
+		c.equals(a, b, level)
+		return
+	}
+	dynamicType := func(v reflect.Value) interface{} {
+		if v.IsNil() {
+			return marker("<nil interface>")
+		}
+		return v.Elem().Type()
+	}
+	if at, bt := dynamicType(a), dynamicType(b); at != bt {
+		c.saveDiff(at, bt)
+	}
+}
+
+// equalsPointer compares pointers a and b by address, not by the values they
+// point to. Other kinds are compared normally.
+func (c *cmp) equalsPointer(a, b reflect.Value, level int) {
+	if a.Kind() != reflect.Ptr && a.Kind() != reflect.UnsafePointer {
+		c.logError(ErrNotHandled)
+		c.equals(a, b, level)
+		return
+	}
+	if a.Pointer() == b.Pointer() {
+		return
+	}
+	address := func(v reflect.Value) marker {
+		if v.Pointer() == 0 {
+			return marker("<nil pointer>")
+		}
+		return marker(fmt.Sprintf("<pointer %#x>", v.Pointer()))
+	}
+	c.saveDiff(address(a), address(b))
+}
+
+// isEmptyValue returns true if v is empty like omitempty; see
+// MissingKeysAreZero.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Interface:
+		return v.IsNil() || isEmptyValue(v.Elem())
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// logError logs err at the current path; see ErrorLogger and LogErrors.
+func (c *cmp) logError(err error) {
+	c.warnings = append(c.warnings, Warning{Path: append([]string(nil), c.buff...), Err: err})
+	if ErrorLogger != nil {
+		ErrorLogger.LogError(strings.Join(c.buff, "."), err)
+	} else if LogErrors {
 		log.Println(err)
 	}
 }
+
+// mapEntry is a map key and value, and the key as printed in paths, like
+// "map[foo]".
+type mapEntry struct {
+	key, val reflect.Value
+	name     string
+}
+
+// mapEntries returns the entries of map m for which keep returns true, or all
+// entries if keep is nil, sorted by name.
+func mapEntries(m reflect.Value, keep func(key reflect.Value) bool) []mapEntry {
+	var entries []mapEntry
+	if keep == nil {
+		entries = make([]mapEntry, 0, m.Len())
+	}
+	iter := m.MapRange()
+	for iter.Next() {
+		if keep != nil && !keep(iter.Key()) {
+			continue
+		}
+		entries = append(entries, mapEntry{
+			key:  iter.Key(),
+			val:  iter.Value(),
+			name: mapKeyName(iter.Key()),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].name == entries[j].name { // like NaN
+			return fmt.Sprint(entries[i].val) < fmt.Sprint(entries[j].val)
+		}
+		return entries[i].name < entries[j].name
+	})
+	return entries
+}
+
+// unequalKey returns true if map key k is not equal to itself, like NaN or
+// a struct with a NaN field, so it cannot be looked up.
+func unequalKey(k reflect.Value) bool {
+	switch k.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return k.Float() != k.Float()
+	case reflect.Complex64, reflect.Complex128:
+		return k.Complex() != k.Complex()
+	case reflect.Interface, reflect.Struct, reflect.Array:
+		return k.CanInterface() && k.Interface() != k.Interface()
+	}
+	return false
+}
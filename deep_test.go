@@ -3,8 +3,12 @@ package deep_test
 import (
 	"errors"
 	"fmt"
+	"math"
+	"math/big"
 	"reflect"
 	"sort"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 	"unsafe"
@@ -1613,3 +1617,1913 @@ func TestNilPointersAreZero(t *testing.T) {
 		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
 	}
 }
+
+func TestElementMatcher(t *testing.T) {
+	type T struct {
+		ID   int
+		Name string
+	}
+	sameID := deep.ElementMatcher(func(a, b T) bool { return a.ID == b.ID })
+
+	a := []T{{1, "foo"}, {2, "bar"}}
+	b := []T{{2, "bar"}, {1, "foo"}}
+	diff := deep.Equal(a, b, sameID)
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+
+	// Paired elements are compared field by field
+	b = []T{{2, "baz"}, {1, "foo"}}
+	diff = deep.Equal(a, b, sameID)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "slice[1].Name: bar != baz" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+
+	// Elements without a match on either side
+	b = []T{{3, "foo"}, {1, "foo"}}
+	diff = deep.Equal(a, b, sameID)
+	if len(diff) != 2 {
+		t.Fatalf("expected 2 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "slice[1]: {2 bar} != <no match>" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+	if diff[1] != "slice[0]: <no match> != {3 foo}" {
+		t.Errorf("wrong diff: %s", diff[1])
+	}
+
+	// Only slices of T use the matcher
+	diff = deep.Equal([]int{1, 2}, []int{2, 1}, sameID)
+	if len(diff) != 2 {
+		t.Fatalf("expected 2 diff, got %d: %s", len(diff), diff)
+	}
+
+	defer func() {
+		if val := recover(); val == nil {
+			t.Error("expected panic for invalid matcher func")
+		}
+	}()
+	deep.ElementMatcher(func(a T) bool { return true })
+}
+
+func TestMapValueIdentity(t *testing.T) {
+	type T struct {
+		Name string
+		Age  int
+	}
+	byName := deep.MapValueIdentity(func(v T) string { return v.Name }, false)
+
+	a := map[string]T{"a1": {"foo", 1}, "a2": {"bar", 2}}
+	b := map[string]T{"b1": {"bar", 2}, "b2": {"foo", 1}}
+	diff := deep.Equal(a, b, byName)
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+
+	// Paired values are compared field by field
+	b["b2"] = T{"foo", 10}
+	diff = deep.Equal(a, b, byName)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "map[a1].Age: 1 != 10" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+
+	// Key remappings are reported separately
+	a = map[string]T{"a1": {"foo", 1}}
+	b = map[string]T{"b1": {"foo", 2}}
+	diff = deep.Equal(a, b, deep.MapValueIdentity(func(v T) string { return v.Name }, true))
+	if len(diff) != 2 {
+		t.Fatalf("expected 2 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "map[a1].(key): a1 != b1" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+	if diff[1] != "map[a1].Age: 1 != 2" {
+		t.Errorf("wrong diff: %s", diff[1])
+	}
+
+	// Values without a match on either side
+	b = map[string]T{"b1": {"bar", 1}}
+	diff = deep.Equal(a, b, byName)
+	if len(diff) != 2 {
+		t.Fatalf("expected 2 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "map[a1]: {foo 1} != <no match>" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+	if diff[1] != "map[b1]: <no match> != {bar 1}" {
+		t.Errorf("wrong diff: %s", diff[1])
+	}
+
+	// Duplicate identities are reported, not dropped
+	a = map[string]T{"a1": {"foo", 1}, "a2": {"foo", 2}}
+	b = map[string]T{"b1": {"foo", 1}, "b2": {"foo", 3}, "b3": {"foo", 4}}
+	diff = deep.Equal(a, b, byName)
+	expect := []string{
+		"map[a2]: duplicate identity: {foo 2} != <no match>",
+		"map[b2]: duplicate identity: <no match> != {foo 3}",
+		"map[b3]: duplicate identity: <no match> != {foo 4}",
+	}
+	if len(diff) != len(expect) {
+		t.Fatalf("expected %d diff, got %d: %s", len(expect), len(diff), diff)
+	}
+	for i := range expect {
+		if diff[i] != expect[i] {
+			t.Errorf("got %q, expected %q", diff[i], expect[i])
+		}
+	}
+
+	// Diffs are in key order, so MaxDiff keeps the same ones
+	defer func(n int) { deep.MaxDiff = n }(deep.MaxDiff)
+	deep.MaxDiff = 2
+	a = map[string]T{"a1": {"foo", 1}, "a2": {"bar", 1}, "a3": {"baz", 1}, "a4": {"qux", 1}}
+	b = map[string]T{"b1": {"foo", 2}, "b2": {"bar", 2}, "b3": {"baz", 2}, "b4": {"qux", 2}}
+	for i := 0; i < 20; i++ {
+		diff = deep.Equal(a, b, byName)
+		if len(diff) != 2 || diff[0] != "map[a1].Age: 1 != 2" || diff[1] != "map[a2].Age: 1 != 2" {
+			t.Fatalf("wrong diffs: %q", diff)
+		}
+	}
+}
+
+func TestCaseInsensitiveMapKeys(t *testing.T) {
+	defaultCaseInsensitiveMapKeys := deep.CaseInsensitiveMapKeys
+	deep.CaseInsensitiveMapKeys = true
+	defer func() { deep.CaseInsensitiveMapKeys = defaultCaseInsensitiveMapKeys }()
+
+	a := map[string]string{"Content-Type": "text/plain", "X-Foo": "1"}
+	b := map[string]string{"content-type": "text/plain", "x-foo": "1"}
+	diff := deep.Equal(a, b)
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+
+	b["x-foo"] = "2"
+	diff = deep.Equal(a, b)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "map[X-Foo]: 1 != 2" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+
+	// Both casings on one side
+	b = map[string]string{"content-type": "text/plain", "x-foo": "1", "X-FOO": "1"}
+	diff = deep.Equal(a, b)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "map[X-Foo]: <key X-Foo> != <duplicate keys X-FOO, x-foo>" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+
+	// Missing key
+	b = map[string]string{"content-type": "text/plain"}
+	diff = deep.Equal(a, b)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "map[X-Foo]: 1 != <does not have key>" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+
+	// Diffs are in key order, so MaxDiff keeps the same ones
+	defer func(n int) { deep.MaxDiff = n }(deep.MaxDiff)
+	deep.MaxDiff = 2
+	a = map[string]string{"e": "1", "D": "1", "c": "1", "B": "1", "a": "1"}
+	b = map[string]string{"E": "2", "d": "2", "C": "2", "b": "2", "A": "2"}
+	for i := 0; i < 20; i++ {
+		diff = deep.Equal(a, b)
+		if len(diff) != 2 || diff[0] != "map[a]: 1 != 2" || diff[1] != "map[B]: 1 != 2" {
+			t.Fatalf("wrong diffs: %q", diff)
+		}
+	}
+	deep.MaxDiff = 10
+
+	// Paths use key formatters like case-sensitive keys
+	type header string
+	keyType := reflect.TypeOf(header(""))
+	deep.RegisterKeyFormatter(keyType, func(k reflect.Value) string {
+		return strings.ToUpper(k.String())
+	})
+	defer deep.RegisterKeyFormatter(keyType, nil)
+	diff = deep.Equal(map[header]int{"X-Foo": 1}, map[header]int{"x-foo": 2})
+	if len(diff) != 1 || diff[0] != "map[X-FOO]: 1 != 2" {
+		t.Errorf("wrong diffs: %q", diff)
+	}
+
+	// Disabled, keys are case-sensitive
+	deep.CaseInsensitiveMapKeys = false
+	a = map[string]string{"Content-Type": "text/plain", "X-Foo": "1"}
+	b = map[string]string{"content-type": "text/plain", "x-foo": "1"}
+	diff = deep.Equal(a, b)
+	if len(diff) != 4 {
+		t.Fatalf("expected 4 diff, got %d: %s", len(diff), diff)
+	}
+}
+
+func TestJSONTagNames(t *testing.T) {
+	defaultJSONTagNames := deep.JSONTagNames
+	deep.JSONTagNames = true
+	defer func() { deep.JSONTagNames = defaultJSONTagNames }()
+
+	type Address struct {
+		Street string `json:"street,omitempty"`
+	}
+	type User struct {
+		UserName string  `json:"user_name"`
+		Email    string  `json:",omitempty"`
+		Internal string  `json:"-"`
+		Address  Address `json:"address"`
+	}
+	a := User{"foo", "foo@example.com", "x", Address{"Main St"}}
+	b := User{"bar", "bar@example.com", "y", Address{"Elm St"}}
+	diff := deep.Equal(a, b)
+	expect := []string{
+		"user_name: foo != bar",
+		"Email: foo@example.com != bar@example.com",
+		"Internal: x != y",
+		"address.street: Main St != Elm St",
+	}
+	if len(diff) != len(expect) {
+		t.Fatalf("expected %d diff, got %d: %s", len(expect), len(diff), diff)
+	}
+	for i := range expect {
+		if diff[i] != expect[i] {
+			t.Errorf("got '%s', expected '%s'", diff[i], expect[i])
+		}
+	}
+}
+
+type pathError struct {
+	Path string
+}
+
+func (e *pathError) Error() string {
+	return "bad path: " + e.Path
+}
+
+func TestEquateErrors(t *testing.T) {
+	errNotFound := errors.New("not found")
+	wrapped := fmt.Errorf("fetch user: %w", errNotFound)
+
+	diff := deep.Equal(wrapped, errNotFound)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+
+	defaultEquateErrors := deep.EquateErrors
+	deep.EquateErrors = true
+	defer func() { deep.EquateErrors = defaultEquateErrors }()
+
+	// Sentinel errors, either way
+	diff = deep.Equal(wrapped, errNotFound)
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+	type T struct {
+		Err error
+	}
+	diff = deep.Equal(T{errNotFound}, T{wrapped})
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+
+	// Wrapped error types are compared
+	var a error = fmt.Errorf("open: %w", &pathError{"/foo"})
+	var b error = &pathError{"/foo"}
+	diff = deep.Equal(T{a}, T{b})
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+	b = &pathError{"/bar"}
+	diff = deep.Equal(T{a}, T{b})
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "Err: bad path: /foo != bad path: /bar" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+
+	// Unrelated errors are still compared by Error string
+	diff = deep.Equal(errors.New("it broke"), errors.New("it fell apart"))
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "it broke != it fell apart" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+
+	// Different error types in unexported fields are a type mismatch
+	deep.CompareUnexportedFields = true
+	defer func() { deep.CompareUnexportedFields = false }()
+	type S struct {
+		err error
+	}
+	diff = deep.Equal(S{errorWithFields{"a", "b", "c"}}, S{errorWithField{"x"}})
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "err: deep_test.errorWithFields != deep_test.errorWithField" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+}
+
+type errorWithFields struct{ A, B, C string }
+
+func (e errorWithFields) Error() string { return e.A + e.B + e.C }
+
+type errorWithField struct{ X string }
+
+func (e errorWithField) Error() string { return e.X }
+
+type fuzzyEqual struct {
+	ID   int
+	Name string
+}
+
+func (f fuzzyEqual) Equal(other fuzzyEqual) bool {
+	return f.ID == other.ID
+}
+
+func TestUseEqualMethod(t *testing.T) {
+	a := fuzzyEqual{1, "foo"}
+	b := fuzzyEqual{1, "bar"}
+	diff := deep.Equal(a, b)
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+
+	deep.UseEqualMethod = false
+	defer func() { deep.UseEqualMethod = true }()
+
+	diff = deep.Equal(a, b)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "Name: foo != bar" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+}
+
+func TestCompareMethod(t *testing.T) {
+	// Cmp(*big.Int) int with a pointer receiver
+	diff := deep.Equal(big.NewInt(1), big.NewInt(1))
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+	type T struct {
+		N *big.Int
+		F *big.Float
+	}
+	a := T{N: big.NewInt(1), F: big.NewFloat(1.5)}
+	b := T{N: big.NewInt(2), F: big.NewFloat(1.5)}
+	diff = deep.Equal(a, b)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "N: 1 != 2" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+
+	// Values that differ internally but compare equal: 1.5 with different precision
+	b = T{N: big.NewInt(1), F: new(big.Float).SetPrec(200).SetFloat64(1.5)}
+	diff = deep.Equal(a, b)
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+
+	// One nil
+	b.N = nil
+	diff = deep.Equal(a, b)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+
+	deep.UseEqualMethod = false
+	defer func() { deep.UseEqualMethod = true }()
+	b = T{N: big.NewInt(2), F: big.NewFloat(1.5)}
+	diff = deep.Equal(a, b)
+	if len(diff) != 0 {
+		// big.Int has only unexported fields
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+}
+
+// protoV1 and protoV2 are like protobuf messages generated by old and new
+// versions of protoc-gen-go
+type protoV1 struct {
+	Name                 string
+	XXX_NoUnkeyedLiteral struct{}
+	XXX_unrecognized     []byte
+	XXX_sizecache        int32
+}
+
+func (*protoV1) ProtoMessage() {}
+
+type protoV2 struct {
+	state         struct{ n int }
+	sizeCache     int32
+	unknownFields []byte
+	Name          string
+}
+
+func (*protoV2) ProtoReflect() interface{} { return nil }
+
+func TestProtoMessage(t *testing.T) {
+	defaultCompareUnexportedFields := deep.CompareUnexportedFields
+	deep.CompareUnexportedFields = true
+	defer func() { deep.CompareUnexportedFields = defaultCompareUnexportedFields }()
+
+	a1 := &protoV1{Name: "foo", XXX_unrecognized: []byte{1}, XXX_sizecache: 10}
+	b1 := &protoV1{Name: "foo"}
+	diff := deep.Equal(a1, b1)
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+
+	a2 := &protoV2{sizeCache: 10, unknownFields: []byte{1}, Name: "foo"}
+	b2 := &protoV2{Name: "bar"}
+	diff = deep.Equal(a2, b2)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "Name: foo != bar" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+
+	deep.IgnoreProtoInternals = false
+	defer func() { deep.IgnoreProtoInternals = true }()
+	diff = deep.Equal(a2, b2)
+	if len(diff) != 3 {
+		t.Fatalf("expected 3 diff, got %d: %s", len(diff), diff)
+	}
+}
+
+type secret string
+
+func TestRedact(t *testing.T) {
+	type Login struct {
+		User     string
+		Password string `deep:"redact"`
+		Token    secret
+		Scopes   []string `deep:"redact"`
+	}
+	a := Login{"foo", "hunter2", "abc", []string{"read"}}
+	b := Login{"foo", "hunter3", "xyz", []string{"write"}}
+	diff := deep.Equal(a, b)
+	expect := []string{
+		"Password: <redacted> != <redacted>",
+		"Token: abc != xyz",
+		"Scopes.slice[0]: <redacted> != <redacted>",
+	}
+	if len(diff) != len(expect) {
+		t.Fatalf("expected %d diff, got %d: %s", len(expect), len(diff), diff)
+	}
+	for i := range expect {
+		if diff[i] != expect[i] {
+			t.Errorf("got '%s', expected '%s'", diff[i], expect[i])
+		}
+	}
+
+	diff = deep.Equal(a, b, deep.RedactTypes(secret("")))
+	if len(diff) != 3 {
+		t.Fatalf("expected 3 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[1] != "Token: <redacted> != <redacted>" {
+		t.Errorf("wrong diff: %s", diff[1])
+	}
+
+	// Unordered slice values are in the diff path
+	diff = deep.Equal(a, b, deep.FLAG_IGNORE_SLICE_ORDER)
+	for _, d := range diff {
+		if strings.Contains(d, "read") || strings.Contains(d, "write") {
+			t.Errorf("value not redacted: %s", d)
+		}
+	}
+
+	// Equal values are not diffs
+	diff = deep.Equal(a, a, deep.RedactTypes(secret("")))
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+}
+
+func TestMaxValueLength(t *testing.T) {
+	defaultMaxValueLength := deep.MaxValueLength
+	deep.MaxValueLength = 4
+	defer func() { deep.MaxValueLength = defaultMaxValueLength }()
+
+	a := strings.Repeat("a", 10*1024)
+	diff := deep.Equal(a, "b")
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "aaaa…(+9KB) != b" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+
+	// Don't split runes
+	diff = deep.Equal("aaa€€", "b")
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "aaa…(+6B) != b" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+
+	deep.MaxValueLength = 0
+	diff = deep.Equal(a, "b")
+	if len(diff[0]) != len(a)+len(" != b") {
+		t.Errorf("value truncated with MaxValueLength = 0")
+	}
+}
+
+func TestSummarizeRepeatedDiffs(t *testing.T) {
+	a := make([]int, 10000)
+	b := make([]int, 10000)
+	for i := 5; i < 9000; i++ {
+		b[i] = 1
+	}
+	b[9500] = 1
+
+	defaultSummarizeRepeatedDiffs := deep.SummarizeRepeatedDiffs
+	deep.SummarizeRepeatedDiffs = 3
+	defer func() { deep.SummarizeRepeatedDiffs = defaultSummarizeRepeatedDiffs }()
+
+	diff := deep.Equal(a, b)
+	expect := []string{
+		"slice[5..8999]: 8995 elements differ",
+		"slice[9500]: 0 != 1",
+	}
+	if len(diff) != len(expect) {
+		t.Fatalf("expected %d diff, got %d: %s", len(expect), len(diff), diff)
+	}
+	for i := range expect {
+		if diff[i] != expect[i] {
+			t.Errorf("got '%s', expected '%s'", diff[i], expect[i])
+		}
+	}
+
+	// Same fields differ
+	type T struct {
+		X, Y int
+	}
+	ta := [4]T{{1, 1}, {2, 2}, {3, 3}, {4, 4}}
+	tb := [4]T{{0, 1}, {0, 2}, {0, 3}, {0, 0}}
+	diff = deep.Equal(ta, tb)
+	expect = []string{
+		"array[0..2].X: 3 elements differ",
+		"array[3].X: 4 != 0",
+		"array[3].Y: 4 != 0",
+	}
+	if len(diff) != len(expect) {
+		t.Fatalf("expected %d diff, got %d: %s", len(expect), len(diff), diff)
+	}
+	for i := range expect {
+		if diff[i] != expect[i] {
+			t.Errorf("got '%s', expected '%s'", diff[i], expect[i])
+		}
+	}
+
+	// Runs shorter than SummarizeRepeatedDiffs are not summarized
+	diff = deep.Equal([]int{1, 1, 0, 1}, []int{0, 0, 0, 0})
+	if len(diff) != 3 {
+		t.Fatalf("expected 3 diff, got %d: %s", len(diff), diff)
+	}
+}
+
+func TestEquateEmpty(t *testing.T) {
+	type T struct {
+		S []int
+		M map[string]int
+		C chan int
+		F func()
+	}
+	a := T{}
+	b := T{S: []int{}, M: map[string]int{}, C: make(chan int, 1)}
+
+	diff := deep.Equal(a, b)
+	if len(diff) != 2 {
+		t.Fatalf("expected 2 diff, got %d: %s", len(diff), diff)
+	}
+
+	deep.EquateEmpty = true
+	defer func() { deep.EquateEmpty = false }()
+
+	diff = deep.Equal(a, b)
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+
+	// Non-empty chans
+	b.C <- 1
+	diff = deep.Equal(a, b)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "C: <nil chan> != chan(len=1)" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+	a.C = b.C
+	diff = deep.Equal(a, b)
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+
+	// Non-empty slices and maps still differ from nil
+	b.S = []int{1}
+	b.M = map[string]int{"a": 1}
+	diff = deep.Equal(a, b)
+	if len(diff) != 2 {
+		t.Fatalf("expected 2 diff, got %d: %s", len(diff), diff)
+	}
+}
+
+type unionNullString struct {
+	Null      *struct{}
+	String    string
+	UnionType int
+}
+
+type avroUser struct {
+	Name             string
+	Email            *string
+	Nick             unionNullString
+	XXX_unrecognized []byte
+}
+
+func TestGeneratedStructs(t *testing.T) {
+	empty := ""
+	a := avroUser{
+		Name:  "a",
+		Email: &empty,
+		Nick:  unionNullString{String: "stale", UnionType: 0},
+	}
+	b := avroUser{
+		Name:             "a",
+		Nick:             unionNullString{Null: &struct{}{}},
+		XXX_unrecognized: []byte{1},
+	}
+	diff := deep.Equal(a, b)
+	if len(diff) != 4 {
+		t.Fatalf("expected 4 diff, got %d: %s", len(diff), diff)
+	}
+
+	diff = deep.Equal(a, b, deep.FLAG_GENERATED_STRUCTS)
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+
+	// Active branch differs
+	a.Nick = unionNullString{String: "x", UnionType: 1}
+	b.Nick = unionNullString{String: "y", UnionType: 1}
+	diff = deep.Equal(a, b, deep.FLAG_GENERATED_STRUCTS)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "Nick.String: x != y" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+
+	// Different branches
+	b.Nick = unionNullString{Null: &struct{}{}, String: "x"}
+	diff = deep.Equal(a, b, deep.FLAG_GENERATED_STRUCTS)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "Nick.UnionType: 1 != 0" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+}
+
+func TestEquateNumericKinds(t *testing.T) {
+	a := map[string]interface{}{"i": 1, "u": uint8(2), "f": float32(1.5), "n": int64(-1)}
+	b := map[string]interface{}{"i": float64(1), "u": 2, "f": 1.5, "n": uint64(1)}
+
+	diff := deep.Equal(a, b)
+	if len(diff) != 4 {
+		t.Fatalf("expected 4 diff, got %d: %s", len(diff), diff)
+	}
+
+	deep.EquateNumericKinds = true
+	defer func() { deep.EquateNumericKinds = false }()
+
+	diff = deep.Equal(a, b)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "map[n]: -1 != 1" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+
+	diff = deep.Equal(1, 1.1)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "1 != 1.1" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+
+	// Non-numbers are still a type mismatch
+	diff = deep.Equal(1, "1")
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+}
+
+func TestKindSchema(t *testing.T) {
+	a := map[string]interface{}{"host": "a", "port": 80.0, "tls": map[string]interface{}{"on": true}}
+	b := map[string]interface{}{"host": "b", "port": "80", "tls": map[string]interface{}{"on": "yes"}}
+	schema := deep.KindSchema(map[string]reflect.Kind{
+		"map[host]":         reflect.String,
+		"map[port]":         reflect.Float64,
+		"map[tls].map[on]":  reflect.Bool,
+		"map[tls].map[off]": reflect.Bool,
+	})
+
+	diff := deep.Equal(a, b, schema)
+	if len(diff) != 3 {
+		t.Fatalf("expected 3 diff, got %d: %s", len(diff), diff)
+	}
+	sort.Strings(diff)
+	expect := []string{
+		"map[host]: a != b",
+		"map[port].(b kind): string != float64",
+		"map[tls].map[on].(b kind): string != bool",
+	}
+	for i := range expect {
+		if diff[i] != expect[i] {
+			t.Errorf("wrong diff %d: %s", i, diff[i])
+		}
+	}
+
+	// Both sides wrong
+	a["port"] = "80"
+	diff = deep.Equal(a, b, schema)
+	sort.Strings(diff)
+	if len(diff) != 4 {
+		t.Fatalf("expected 4 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[1] != "map[port].(a kind): string != float64" {
+		t.Errorf("wrong diff: %s", diff[1])
+	}
+}
+
+type userID string
+
+func TestConvertibleTypes(t *testing.T) {
+	type user struct {
+		ID interface{}
+	}
+	a := user{ID: userID("u1")}
+	b := user{ID: "u1"}
+
+	diff := deep.Equal(a, b)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "ID: deep_test.userID != string" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+
+	deep.ConvertibleTypes = true
+	defer func() { deep.ConvertibleTypes = false }()
+
+	diff = deep.Equal(a, b)
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+
+	b.ID = "u2"
+	diff = deep.Equal(a, b)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "ID: u1 != u2" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+
+	// Different underlying types are still a type mismatch
+	b.ID = 1
+	diff = deep.Equal(a, b)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "ID: deep_test.userID != int" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+}
+
+func TestRedactPaths(t *testing.T) {
+	type Creds struct {
+		User  string
+		Token string
+	}
+	type Config struct {
+		Password string
+		Admin    Creds
+		Service  Creds
+		Env      map[string]string
+	}
+	a := Config{"hunter2", Creds{"root", "abc"}, Creds{"svc", "def"}, map[string]string{"API_KEY": "k1"}}
+	b := Config{"hunter3", Creds{"admin", "xyz"}, Creds{"svc", "uvw"}, map[string]string{"API_KEY": "k2"}}
+	diff := deep.Equal(a, b, deep.Redact("Password", "*.Token", "Env.map[*_KEY]"))
+	expect := []string{
+		"Password: <redacted> != <redacted>",
+		"Admin.User: root != admin",
+		"Admin.Token: <redacted> != <redacted>",
+		"Service.Token: <redacted> != <redacted>",
+		"Env.map[API_KEY]: <redacted> != <redacted>",
+	}
+	if len(diff) != len(expect) {
+		t.Fatalf("expected %d diff, got %d: %s", len(expect), len(diff), diff)
+	}
+	for i := range expect {
+		if diff[i] != expect[i] {
+			t.Errorf("got '%s', expected '%s'", diff[i], expect[i])
+		}
+	}
+
+	// Subtree of a matching path
+	diff = deep.Equal(a, b, deep.Redact("Admin"))
+	if len(diff) != 5 {
+		t.Fatalf("expected 5 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[1] != "Admin.User: <redacted> != <redacted>" {
+		t.Errorf("wrong diff: %s", diff[1])
+	}
+	if diff[3] != "Service.Token: def != uvw" {
+		t.Errorf("wrong diff: %s", diff[3])
+	}
+}
+
+func TestHashPaths(t *testing.T) {
+	type Account struct {
+		User     string
+		Password string
+		PIN      int
+	}
+	a := Account{"foo", "hunter2", 1234}
+	b := Account{"bar", "hunter3", 1234}
+	diff := deep.Equal(a, b, deep.HashPaths([]byte("salt"), "Password", "PIN"))
+	if len(diff) != 2 {
+		t.Fatalf("expected 2 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "User: foo != bar" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+	if strings.Contains(diff[1], "hunter") || !strings.HasPrefix(diff[1], "Password: hash ") {
+		t.Errorf("value not hashed: %s", diff[1])
+	}
+
+	// Hashes are stable for the same salt and differ for another salt
+	diff2 := deep.Equal(a, b, deep.HashPaths([]byte("salt"), "Password"))
+	if diff2[1] != diff[1] {
+		t.Errorf("hash not stable: %s != %s", diff2[1], diff[1])
+	}
+	diff2 = deep.Equal(a, b, deep.HashPaths([]byte("pepper"), "Password"))
+	if diff2[1] == diff[1] {
+		t.Errorf("hash not salted: %s", diff2[1])
+	}
+}
+
+func TestIgnoreSyncTypes(t *testing.T) {
+	type counter struct {
+		mu sync.Mutex
+		wg sync.WaitGroup
+		n  int
+	}
+	a := &counter{n: 1}
+	b := &counter{n: 1}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.wg.Add(1)
+	defer b.wg.Done()
+
+	deep.CompareUnexportedFields = true
+	defer func() { deep.CompareUnexportedFields = false }()
+
+	diff := deep.Equal(a, b)
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+
+	b.n = 2
+	diff = deep.Equal(a, b)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "n: 1 != 2" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+
+	deep.IgnoreSyncTypes = false
+	defer func() { deep.IgnoreSyncTypes = true }()
+
+	diff = deep.Equal(a, b)
+	if len(diff) < 2 {
+		t.Fatalf("expected lock state diffs, got %d: %s", len(diff), diff)
+	}
+}
+
+func TestSampleElementDiffs(t *testing.T) {
+	type Item struct {
+		SKU   string
+		Price int
+		Qty   int
+	}
+	a := make([]Item, 5000)
+	b := make([]Item, 5000)
+	for i := range b {
+		b[i].Price = 1
+		if i%2 == 0 {
+			b[i].Qty = 1
+		}
+	}
+
+	deep.SampleElementDiffs = 2
+	defer func() { deep.SampleElementDiffs = 0 }()
+
+	diff := deep.Equal(a, b)
+	expect := []string{
+		"slice[0].Price: 0 != 1",
+		"slice[0].Qty: 0 != 1",
+		"slice[1].Price: 0 != 1",
+		"slice[*].Price: 4998 more elements differ",
+		"slice[*].Qty: 2499 more elements differ",
+	}
+	if len(diff) != len(expect) {
+		t.Fatalf("expected %d diff, got %d: %s", len(expect), len(diff), diff)
+	}
+	for i := range expect {
+		if diff[i] != expect[i] {
+			t.Errorf("got '%s', expected '%s'", diff[i], expect[i])
+		}
+	}
+
+	// Elements without fields
+	diff = deep.Equal([3]int{1, 2, 3}, [3]int{4, 5, 6})
+	expect = []string{
+		"array[0]: 1 != 4",
+		"array[1]: 2 != 5",
+		"array[*]: 1 more elements differ",
+	}
+	if len(diff) != len(expect) {
+		t.Fatalf("expected %d diff, got %d: %s", len(expect), len(diff), diff)
+	}
+	for i := range expect {
+		if diff[i] != expect[i] {
+			t.Errorf("got '%s', expected '%s'", diff[i], expect[i])
+		}
+	}
+}
+
+func TestAllowUnsafe(t *testing.T) {
+	deep.CompareUnexportedFields = true
+	deep.AllowUnsafe = true
+	defer func() {
+		deep.CompareUnexportedFields = false
+		deep.AllowUnsafe = false
+	}()
+
+	type hiddenTime struct {
+		t   time.Time
+		err error
+	}
+	now := time.Now()
+	a := hiddenTime{t: now, err: errors.New("foo")}
+	b := hiddenTime{t: now.UTC(), err: errors.New("foo")}
+	diff := deep.Equal(a, b)
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+
+	// Values, not internals, in diffs
+	b.t = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	b.err = errors.New("bar")
+	diff = deep.Equal(&a, &b)
+	if len(diff) != 2 {
+		t.Fatalf("expected 2 diff, got %d: %s", len(diff), diff)
+	}
+	if !strings.HasSuffix(diff[0], " != 2020-01-01 00:00:00 +0000 UTC") {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+	if diff[1] != "err: foo != bar" {
+		t.Errorf("wrong diff: %s", diff[1])
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	type T struct {
+		Name  string
+		Data  []byte
+		Owner *T
+	}
+	deep.FormatValue = func(v reflect.Value) string {
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return fmt.Sprintf("%x", v.Bytes())
+		}
+		if v.CanInterface() {
+			return fmt.Sprintf("%#v", v.Interface())
+		}
+		return fmt.Sprintf("%#v", v)
+	}
+	defer func() { deep.FormatValue = nil }()
+
+	a := T{Name: "a", Data: []byte{0xca, 0xfe}}
+	b := T{Name: "b", Owner: &T{}}
+	diff := deep.Equal(a, b)
+	expect := []string{
+		`Name: "a" != "b"`,
+		"Data: cafe != <nil slice>",
+		"Owner: <nil pointer> != deep_test.T",
+	}
+	if len(diff) != len(expect) {
+		t.Fatalf("expected %d diff, got %d: %s", len(expect), len(diff), diff)
+	}
+	for i := range expect {
+		if diff[i] != expect[i] {
+			t.Errorf("got '%s', expected '%s'", diff[i], expect[i])
+		}
+	}
+}
+
+type value int
+
+func (v value) String() string {
+	return []string{"VALUE_ZERO", "VALUE_ONE"}[v]
+}
+
+type sameString struct {
+	N int
+}
+
+func (sameString) String() string {
+	return "same"
+}
+
+func (s sameString) Equal(t sameString) bool {
+	return s.N == t.N
+}
+
+func TestUseStringer(t *testing.T) {
+	type T struct {
+		V value
+		P *value
+		S sameString
+	}
+	one := value(1)
+	a := T{P: nil, S: sameString{1}}
+	b := T{P: &one, S: sameString{2}}
+	diff := deep.Equal(map[string]T{"k": a}, map[string]T{})
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "map[k]: {VALUE_ZERO <nil> same} != <does not have key>" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+
+	// Same String, different values
+	diff = deep.Equal(a, b)
+	if len(diff) != 2 {
+		t.Fatalf("expected 2 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[1] != "S: {1} != {2}" {
+		t.Errorf("wrong diff: %s", diff[1])
+	}
+
+	deep.UseStringer = false
+	defer func() { deep.UseStringer = true }()
+
+	diff = deep.Equal(map[string]T{"k": a}, map[string]T{})
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "map[k]: {0 <nil> {1}} != <does not have key>" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+}
+
+type panicky struct {
+	m map[string]int
+}
+
+func (p panicky) Equal(q panicky) bool {
+	p.m["x"] = 1 // panics on nil map
+	return true
+}
+
+func (p *panicky) Error() string {
+	return fmt.Sprint(p.m["x"] / len(p.m)) // panics on empty map
+}
+
+func (p panicky) String() string {
+	panic("bad String")
+}
+
+func TestPanickingMethods(t *testing.T) {
+	type T struct {
+		P   panicky
+		Err error
+	}
+	a := T{Err: &panicky{}}
+	b := T{Err: &panicky{m: map[string]int{"x": 1}}}
+	diff := deep.Equal(a, b)
+	expect := []string{
+		"P: <Equal panicked: assignment to entry in nil map> != <Equal panicked: assignment to entry in nil map>",
+		"Err: <Error panicked: runtime error: integer divide by zero> != 1",
+	}
+	if len(diff) != len(expect) {
+		t.Fatalf("expected %d diff, got %d: %s", len(expect), len(diff), diff)
+	}
+	for i := range expect {
+		if diff[i] != expect[i] {
+			t.Errorf("got '%s', expected '%s'", diff[i], expect[i])
+		}
+	}
+
+	// String panics when printing values are handled by fmt
+	diff = deep.Equal(map[string]panicky{"k": {}}, map[string]panicky{})
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if !strings.Contains(diff[0], "PANIC=String method: bad String") {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+}
+
+func TestTimeAndDurationPrecision(t *testing.T) {
+	type Job struct {
+		Start   time.Time
+		Elapsed time.Duration
+		Timeout time.Duration `deep:"precision=1m"`
+	}
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	a := Job{start, 1500 * time.Millisecond, 10 * time.Minute}
+	b := Job{start.Add(300 * time.Millisecond), 1900 * time.Millisecond, 10*time.Minute + 30*time.Second}
+
+	diff := deep.Equal(a, b)
+	if len(diff) != 2 {
+		t.Fatalf("expected 2 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[1] != "Elapsed: 1500000000 != 1900000000" {
+		t.Errorf("wrong diff: %s", diff[1])
+	}
+
+	deep.TimePrecision = time.Second
+	defer func() { deep.TimePrecision = 0 }()
+
+	diff = deep.Equal(a, b)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "Elapsed: 1500000000 != 1900000000" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+
+	deep.DurationPrecision = time.Second
+	defer func() { deep.DurationPrecision = 0 }()
+
+	diff = deep.Equal(a, b)
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+
+	// Tag precision
+	b.Timeout = 11 * time.Minute
+	diff = deep.Equal(a, b)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "Timeout: 600000000000 != 660000000000" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+
+	// Invalid tag precision keeps the precision of the parent field
+	type Limits struct {
+		Timeout time.Duration `deep:"precision=bad"`
+	}
+	type Config struct {
+		Limits Limits `deep:"precision=1m"`
+	}
+	diff = deep.Equal(Config{Limits{10 * time.Minute}}, Config{Limits{10*time.Minute + 30*time.Second}})
+	if len(diff) != 0 {
+		t.Errorf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+}
+
+func TestEquateTimeLocation(t *testing.T) {
+	type event struct {
+		At time.Time
+		at time.Time
+	}
+	utc := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	local := utc.In(time.FixedZone("CEST", 2*60*60))
+	a := event{At: utc, at: utc}
+	b := event{At: local, at: local}
+
+	deep.CompareUnexportedFields = true
+	defer func() { deep.CompareUnexportedFields = false }()
+
+	diff := deep.Equal(a, b)
+	if len(diff) == 0 {
+		t.Fatal("expected diffs for unexported location")
+	}
+
+	deep.EquateTimeLocation = true
+	defer func() { deep.EquateTimeLocation = false }()
+
+	diff = deep.Equal(a, b)
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+
+	// Diffs in UTC
+	b.At = b.At.Add(time.Hour)
+	deep.CompareUnexportedFields = false
+	diff = deep.Equal(a, b)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "At: 2024-01-01 10:00:00 +0000 UTC != 2024-01-01 11:00:00 +0000 UTC" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+}
+
+func TestMapKeyOrder(t *testing.T) {
+	a := map[string]int{}
+	b := map[string]int{}
+	for i := 0; i < 5; i++ {
+		a[fmt.Sprintf("k%d", i)] = i
+		b[fmt.Sprintf("k%d", i)] = i + 1
+	}
+	a["a-only"] = 1
+	b["b-only"] = 1
+	expect := []string{
+		"map[a-only]: 1 != <does not have key>",
+		"map[k0]: 0 != 1",
+		"map[k1]: 1 != 2",
+		"map[k2]: 2 != 3",
+		"map[k3]: 3 != 4",
+		"map[k4]: 4 != 5",
+		"map[b-only]: <does not have key> != 1",
+	}
+	for i := 0; i < 10; i++ {
+		if diff := deep.Equal(deep.Equal(a, b), expect); diff != nil {
+			t.Fatal(diff)
+		}
+	}
+}
+
+func TestNaNMapKeys(t *testing.T) {
+	nan := math.NaN()
+
+	// Two NaN keys can't be looked up, so they're paired in order
+	a := map[float64]string{nan: "x", 1: "one"}
+	b := map[float64]string{nan: "x", 1: "one"}
+	if diff := deep.Equal(a, b); diff != nil {
+		t.Error(diff)
+	}
+
+	b[nan] = "y" // another NaN key
+	diff := deep.Equal(a, b)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "map[NaN]: <does not have key> != y" {
+		t.Error("wrong diff:", diff[0])
+	}
+
+	diff = deep.Equal(b, a)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "map[NaN]: y != <does not have key>" {
+		t.Error("wrong diff:", diff[0])
+	}
+
+	// map[interface{}]interface{} with NaN, complex, and struct keys
+	type point struct{ X, Y float64 }
+	m1 := map[interface{}]interface{}{
+		nan:                 1,
+		complex(nan, 0):     2,
+		point{nan, 0}:       3,
+		point{1, 2}:         4,
+		[2]string{"a", "b"}: 5,
+	}
+	m2 := map[interface{}]interface{}{
+		nan:                 1,
+		complex(nan, 0):     2,
+		point{nan, 0}:       30,
+		point{1, 2}:         4,
+		[2]string{"a", "c"}: 5,
+	}
+	expect := []string{
+		"map[[a b]]: 5 != <does not have key>",
+		"map[{X:NaN Y:0}]: 3 != 30",
+		"map[[a c]]: <does not have key> != 5",
+	}
+	for i := 0; i < 10; i++ {
+		if diff := deep.Equal(deep.Equal(m1, m2), expect); diff != nil {
+			t.Fatal(diff)
+		}
+	}
+}
+
+func TestMaxOps(t *testing.T) {
+	defer func(n int) { deep.MaxOps = n }(deep.MaxOps)
+	deep.MaxOps = 10
+
+	type list struct {
+		Next *list
+	}
+	a, b := &list{}, &list{}
+	for i := 0; i < 100; i++ {
+		a, b = &list{Next: a}, &list{Next: b}
+	}
+
+	diff := deep.Equal(a, b)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	expect := "Next.Next.Next.Next.Next: <stopped after MaxOps=10> != <stopped after MaxOps=10>"
+	if diff[0] != expect {
+		t.Errorf("got %s, expected %s", diff[0], expect)
+	}
+
+	// Not reached
+	if diff := deep.Equal([]int{1, 2, 3}, []int{1, 2, 3}); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestCycles(t *testing.T) {
+	type node struct {
+		Value int
+		Next  *node
+	}
+
+	// a -> a and b -> b
+	a := &node{Value: 1}
+	a.Next = a
+	b := &node{Value: 1}
+	b.Next = b
+	if diff := deep.Equal(a, b); diff != nil {
+		t.Error(diff)
+	}
+
+	// a1 -> a2 -> a1 and b1 -> b2 -> b1
+	a1, a2 := &node{Value: 1}, &node{Value: 2}
+	a1.Next, a2.Next = a2, a1
+	b1, b2 := &node{Value: 1}, &node{Value: 3}
+	b1.Next, b2.Next = b2, b1
+	diff := deep.Equal(a1, b1)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "Next.Value: 2 != 3" {
+		t.Error("wrong diff:", diff[0])
+	}
+
+	// Map that contains itself
+	m1 := map[string]interface{}{"v": 1}
+	m1["self"] = m1
+	m2 := map[string]interface{}{"v": 1}
+	m2["self"] = m2
+	if diff := deep.Equal(m1, m2); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestSharedNodes(t *testing.T) {
+	type node struct {
+		Value int
+	}
+	type tree struct {
+		L, R *node
+	}
+	// x is compared to x, then to y: tracking pointers individually, not
+	// pairs, would skip comparing x to y
+	x, y := &node{1}, &node{2}
+	diff := deep.Equal(tree{L: x, R: x}, tree{L: x, R: y})
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "R.Value: 1 != 2" {
+		t.Error("wrong diff:", diff[0])
+	}
+}
+
+func TestEqualValues(t *testing.T) {
+	type user struct {
+		Name string
+		Age  int
+	}
+	a := []user{{"alice", 30}}
+	b := []user{{"alice", 31}}
+
+	diff := deep.EqualValues(reflect.ValueOf(a).Index(0), reflect.ValueOf(b).Index(0))
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "Age: 30 != 31" {
+		t.Error("wrong diff:", diff[0])
+	}
+
+	if diff := deep.EqualValues(reflect.ValueOf(a), reflect.ValueOf(a)); diff != nil {
+		t.Error(diff)
+	}
+	if diff := deep.EqualValues(reflect.Value{}, reflect.Value{}); diff != nil {
+		t.Error(diff)
+	}
+	diff = deep.EqualValues(reflect.ValueOf(1), reflect.Value{})
+	if len(diff) != 1 || diff[0] != "int != <nil pointer>" {
+		t.Errorf("wrong diffs: %v", diff)
+	}
+
+	// Addressable unexported field compared with AllowUnsafe
+	deep.CompareUnexportedFields = true
+	deep.AllowUnsafe = true
+	defer func() {
+		deep.CompareUnexportedFields = false
+		deep.AllowUnsafe = false
+	}()
+	type hidden struct {
+		err error
+	}
+	x := &hidden{errors.New("foo")}
+	y := &hidden{errors.New("bar")}
+	diff = deep.EqualValues(reflect.ValueOf(x).Elem().Field(0), reflect.ValueOf(y).Elem().Field(0))
+	if len(diff) != 1 || diff[0] != "foo != bar" {
+		t.Errorf("wrong diffs: %v", diff)
+	}
+}
+
+func TestOnCompare(t *testing.T) {
+	type user struct {
+		Name string
+		Tags []string
+		Age  int
+	}
+	var visited []string
+	fn := func(path []string, a, b reflect.Value, equal bool) {
+		visited = append(visited, fmt.Sprintf("%s %t", strings.Join(path, "."), equal))
+	}
+	a := &user{"alice", []string{"x"}, 30}
+	b := &user{"alice", []string{"y"}, 30}
+	diff := deep.Equal(a, b, deep.OnCompare(fn), deep.IgnorePaths("Age"))
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	expect := []string{
+		"Name true",
+		"Tags.slice[0] false",
+		"Tags false",
+		" false", // user
+		" false", // *user
+	}
+	if d := deep.Equal(visited, expect); d != nil {
+		t.Error(d)
+	}
+}
+
+func TestEqualWantGot(t *testing.T) {
+	type T struct {
+		Name string
+		N    int
+		P    *int
+	}
+	n := 1
+	diff := deep.EqualWantGot(T{Name: "foo", N: 1}, T{Name: "bar", N: 2, P: &n})
+	want := []string{
+		`Name: want "foo", got "bar"`,
+		`N: want 1, got 2`,
+		`P: want <nil pointer>, got int`,
+	}
+	if len(diff) != len(want) {
+		t.Fatalf("got %d diffs, expected %d: %v", len(diff), len(want), diff)
+	}
+	for i := range want {
+		if diff[i] != want[i] {
+			t.Errorf("got %q, expected %q", diff[i], want[i])
+		}
+	}
+
+	// Normal diffs are not affected
+	diff = deep.Equal("foo", "bar")
+	if len(diff) != 1 || diff[0] != "foo != bar" {
+		t.Errorf("got %v", diff)
+	}
+}
+
+func TestFieldFilter(t *testing.T) {
+	defer func(f func(reflect.StructField, reflect.Type) bool) { deep.FieldFilter = f }(deep.FieldFilter)
+	deep.FieldFilter = func(f reflect.StructField, t reflect.Type) bool {
+		return !strings.HasPrefix(f.Name, "Internal") && f.Tag.Get("status") != "deprecated"
+	}
+
+	type T struct {
+		Name       string
+		InternalID int
+		Old        string `status:"deprecated"`
+	}
+	a := T{Name: "a", InternalID: 1, Old: "x"}
+	b := T{Name: "b", InternalID: 2, Old: "y"}
+	diff := deep.Equal(a, b)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %v", len(diff), diff)
+	}
+	if diff[0] != "Name: a != b" {
+		t.Errorf("got %q", diff[0])
+	}
+
+	var got reflect.Type
+	deep.FieldFilter = func(f reflect.StructField, t reflect.Type) bool {
+		got = t
+		return true
+	}
+	deep.Equal(a, b)
+	if got != reflect.TypeOf(a) {
+		t.Errorf("got struct type %v, expected %v", got, reflect.TypeOf(a))
+	}
+}
+
+func TestPathFormat(t *testing.T) {
+	defer func(sep, root string, compact bool) {
+		deep.PathSeparator, deep.PathRoot, deep.CompactIndexes = sep, root, compact
+	}(deep.PathSeparator, deep.PathRoot, deep.CompactIndexes)
+
+	type item struct {
+		Name string
+	}
+	type T struct {
+		Items  []item
+		Labels map[string]string
+	}
+	a := T{Items: []item{{"a"}}, Labels: map[string]string{"foo": "x"}}
+	b := T{Items: []item{{"b"}}, Labels: map[string]string{"foo": "y"}}
+
+	deep.PathSeparator = "/"
+	deep.PathRoot = "want"
+	diff := deep.Equal(a, b)
+	want := []string{
+		"want/Items/slice[0]/Name: a != b",
+		"want/Labels/map[foo]: x != y",
+	}
+	if len(diff) != len(want) {
+		t.Fatalf("got %d diffs, expected %d: %v", len(diff), len(want), diff)
+	}
+	for i := range want {
+		if diff[i] != want[i] {
+			t.Errorf("got %q, expected %q", diff[i], want[i])
+		}
+	}
+	if diff := deep.Equal(1, 2); len(diff) != 1 || diff[0] != "want: 1 != 2" {
+		t.Errorf("got %v", diff)
+	}
+
+	deep.PathSeparator = "."
+	deep.PathRoot = ""
+	deep.CompactIndexes = true
+	diff = deep.Equal(a, b)
+	want = []string{
+		"Items[0].Name: a != b",
+		"Labels[foo]: x != y",
+	}
+	if len(diff) != len(want) {
+		t.Fatalf("got %d diffs, expected %d: %v", len(diff), len(want), diff)
+	}
+	for i := range want {
+		if diff[i] != want[i] {
+			t.Errorf("got %q, expected %q", diff[i], want[i])
+		}
+	}
+	if diff := deep.Equal([]int{1}, []int{2}); len(diff) != 1 || diff[0] != "[0]: 1 != 2" {
+		t.Errorf("got %v", diff)
+	}
+}
+
+func TestErrorLogger(t *testing.T) {
+	defer func(l deep.Logger) { deep.ErrorLogger = l }(deep.ErrorLogger)
+	var paths []string
+	var errs []error
+	deep.ErrorLogger = deep.LoggerFunc(func(path string, err error) {
+		paths = append(paths, path)
+		errs = append(errs, err)
+	})
+
+	type T struct {
+		V interface{}
+	}
+	diff := deep.Equal(T{1}, T{"1"})
+	if len(diff) != 1 || diff[0] != "V: int != string" {
+		t.Errorf("got %v", diff)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], deep.ErrTypeMismatch) || paths[0] != "V" {
+		t.Errorf("got paths %v, errors %v", paths, errs)
+	}
+}
+
+type fastRunner struct{ N int }
+
+func (fastRunner) Run() {}
+
+type slowRunner struct{ N int }
+
+func (*slowRunner) Run() {}
+
+func TestInterfaceFieldTypesOnly(t *testing.T) {
+	type runner interface{ Run() }
+	type T struct {
+		Strategy runner
+		Fallback runner `deep:"type"`
+		Attrs    map[string]interface{}
+	}
+	a := T{Strategy: fastRunner{1}, Fallback: fastRunner{1}, Attrs: map[string]interface{}{"n": 1}}
+	b := T{Strategy: fastRunner{2}, Fallback: fastRunner{2}, Attrs: map[string]interface{}{"n": 1}}
+
+	// Tag only
+	diff := deep.Equal(a, b)
+	if len(diff) != 1 || diff[0] != "Strategy.N: 1 != 2" {
+		t.Errorf("got %v", diff)
+	}
+
+	defer func(v bool) { deep.InterfaceFieldTypesOnly = v }(deep.InterfaceFieldTypesOnly)
+	deep.InterfaceFieldTypesOnly = true
+	if diff := deep.Equal(a, b); diff != nil {
+		t.Error(diff)
+	}
+
+	b.Strategy = &slowRunner{1}
+	b.Fallback = nil
+	b.Attrs["n"] = 2
+	diff = deep.Equal(a, b)
+	want := []string{
+		"Strategy: deep_test.fastRunner != *deep_test.slowRunner",
+		"Fallback: deep_test.fastRunner != <nil interface>",
+		"Attrs.map[n]: 1 != 2",
+	}
+	if len(diff) != len(want) {
+		t.Fatalf("got %d diffs, expected %d: %v", len(diff), len(want), diff)
+	}
+	for i := range want {
+		if diff[i] != want[i] {
+			t.Errorf("got %q, expected %q", diff[i], want[i])
+		}
+	}
+
+	// The tag on a non-interface field is ignored
+	type U struct {
+		N int `deep:"type"`
+	}
+	diff = deep.Equal(U{1}, U{2})
+	if len(diff) != 1 || diff[0] != "N: 1 != 2" {
+		t.Errorf("got %v", diff)
+	}
+}
+
+func TestNoDerefTag(t *testing.T) {
+	type config struct {
+		Name string
+	}
+	type T struct {
+		Config *config `deep:"noderef"`
+		Parent *config
+	}
+	shared := &config{"shared"}
+	a := T{Config: shared, Parent: &config{"p"}}
+	b := T{Config: shared, Parent: &config{"p"}}
+	if diff := deep.Equal(a, b); diff != nil {
+		t.Error(diff)
+	}
+
+	b.Config = &config{"shared"} // equal value, different pointer
+	diff := deep.Equal(a, b)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %v", len(diff), diff)
+	}
+	want := fmt.Sprintf("Config: <pointer %p> != <pointer %p>", a.Config, b.Config)
+	if diff[0] != want {
+		t.Errorf("got %q, expected %q", diff[0], want)
+	}
+
+	b.Config = nil
+	diff = deep.Equal(a, b)
+	if len(diff) != 1 || !strings.HasSuffix(diff[0], " != <nil pointer>") {
+		t.Errorf("got %v", diff)
+	}
+}
+
+func TestMissingKeysAreZero(t *testing.T) {
+	a := map[string]interface{}{"name": "a", "count": 0, "tags": []string{}, "opt": nil}
+	b := map[string]interface{}{"name": "a", "extra": "", "zero": 0.0}
+	diff := deep.Equal(a, b)
+	if len(diff) != 5 {
+		t.Errorf("expected 5 diffs, got %d: %v", len(diff), diff)
+	}
+
+	defer func(v bool) { deep.MissingKeysAreZero = v }(deep.MissingKeysAreZero)
+	deep.MissingKeysAreZero = true
+	if diff := deep.Equal(a, b); diff != nil {
+		t.Error(diff)
+	}
+
+	a["count"] = 1
+	b["extra"] = "x"
+	diff = deep.Equal(a, b)
+	want := []string{
+		"map[count]: 1 != <does not have key>",
+		"map[extra]: <does not have key> != x",
+	}
+	if len(diff) != len(want) {
+		t.Fatalf("got %d diffs, expected %d: %v", len(diff), len(want), diff)
+	}
+	for i := range want {
+		if diff[i] != want[i] {
+			t.Errorf("got %q, expected %q", diff[i], want[i])
+		}
+	}
+
+	// Present keys are still compared
+	if diff := deep.Equal(map[string]int{"a": 0}, map[string]int{"a": 1}); len(diff) != 1 {
+		t.Errorf("got %v", diff)
+	}
+}
+
+func TestIgnoreZeroFields(t *testing.T) {
+	type inner struct {
+		X, Y int
+	}
+	type T struct {
+		Name  string
+		Count int
+		Tags  []string
+		In    inner
+	}
+	a := T{Name: "foo", In: inner{X: 1}}
+	b := T{Name: "foo", Count: 2, Tags: []string{"x"}, In: inner{X: 1, Y: 2}}
+	if diff := deep.Equal(a, b); len(diff) != 3 {
+		t.Errorf("expected 3 diffs, got %d: %v", len(diff), diff)
+	}
+
+	defer func(v bool) { deep.IgnoreZeroFields = v }(deep.IgnoreZeroFields)
+	deep.IgnoreZeroFields = true
+	if diff := deep.Equal(a, b); diff != nil {
+		t.Error(diff)
+	}
+
+	// Only zero fields in a are ignored
+	diff := deep.Equal(b, a)
+	want := []string{
+		"Count: 2 != 0",
+		"Tags: [x] != <nil slice>",
+		"In.Y: 2 != 0",
+	}
+	if len(diff) != len(want) {
+		t.Fatalf("got %d diffs, expected %d: %v", len(diff), len(want), diff)
+	}
+	for i := range want {
+		if diff[i] != want[i] {
+			t.Errorf("got %q, expected %q", diff[i], want[i])
+		}
+	}
+}
+
+func TestOptionalTag(t *testing.T) {
+	type T struct {
+		ID        int
+		RequestID string   `deep:"optional"`
+		Tags      []string `deep:"optional,sorted"`
+	}
+	a := T{ID: 1, RequestID: "abc", Tags: []string{"b", "a"}}
+	b := T{ID: 1}
+	if diff := deep.Equal(a, b); diff != nil {
+		t.Error(diff)
+	}
+	if diff := deep.Equal(b, a); diff != nil {
+		t.Error(diff)
+	}
+
+	// Compared if both are set
+	b = T{ID: 2, RequestID: "xyz", Tags: []string{"a", "b"}}
+	diff := deep.Equal(a, b)
+	want := []string{
+		"ID: 1 != 2",
+		"RequestID: abc != xyz",
+	}
+	if len(diff) != len(want) {
+		t.Fatalf("got %d diffs, expected %d: %v", len(diff), len(want), diff)
+	}
+	for i := range want {
+		if diff[i] != want[i] {
+			t.Errorf("got %q, expected %q", diff[i], want[i])
+		}
+	}
+}
+
+func TestMaxDiffPerPath(t *testing.T) {
+	defer func(n int) { deep.MaxDiffPerPath = n }(deep.MaxDiffPerPath)
+	type T struct {
+		Items []int
+		Name  string
+		Count int
+	}
+	a := T{Items: make([]int, 100), Name: "a", Count: 1}
+	b := T{Items: make([]int, 100), Name: "b", Count: 2}
+	for i := range b.Items {
+		b.Items[i] = i + 1
+	}
+
+	// Items uses all MaxDiff diffs
+	diff := deep.Equal(a, b)
+	if len(diff) != deep.MaxDiff || diff[len(diff)-1] != "Items.slice[9]: 0 != 10" {
+		t.Errorf("got %v", diff)
+	}
+
+	deep.MaxDiffPerPath = 3
+	diff = deep.Equal(a, b)
+	want := []string{
+		"Items.slice[0]: 0 != 1",
+		"Items.slice[1]: 0 != 2",
+		"Items.slice[2]: 0 != 3",
+		"Items: <stopped after MaxDiffPerPath=3> != <stopped after MaxDiffPerPath=3>",
+		"Name: a != b",
+		"Count: 1 != 2",
+	}
+	if len(diff) != len(want) {
+		t.Fatalf("got %d diffs, expected %d: %v", len(diff), len(want), diff)
+	}
+	for i := range want {
+		if diff[i] != want[i] {
+			t.Errorf("got %q, expected %q", diff[i], want[i])
+		}
+	}
+}
+
+func TestMaxDiffPerPathSummarized(t *testing.T) {
+	defer func(n, r, s int) {
+		deep.MaxDiffPerPath, deep.SummarizeRepeatedDiffs, deep.SampleElementDiffs = n, r, s
+	}(deep.MaxDiffPerPath, deep.SummarizeRepeatedDiffs, deep.SampleElementDiffs)
+	type T struct {
+		Items []int
+		Other int
+	}
+	a := T{Items: make([]int, 10), Other: 1}
+	b := T{Items: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, Other: 2}
+	deep.MaxDiffPerPath = 2
+	want := []string{
+		"Items.slice[0]: 0 != 1",
+		"Items.slice[1]: 0 != 2",
+		"Items: <stopped after MaxDiffPerPath=2> != <stopped after MaxDiffPerPath=2>",
+		"Other: 1 != 2",
+	}
+
+	deep.SummarizeRepeatedDiffs = 3
+	diff := deep.Equal(a, b)
+	if len(diff) != len(want) {
+		t.Fatalf("SummarizeRepeatedDiffs: got %d diffs, expected %d: %v", len(diff), len(want), diff)
+	}
+	for i := range want {
+		if diff[i] != want[i] {
+			t.Errorf("SummarizeRepeatedDiffs: got %q, expected %q", diff[i], want[i])
+		}
+	}
+
+	deep.SummarizeRepeatedDiffs, deep.SampleElementDiffs = 0, 1
+	want = []string{
+		"Items.slice[0]: 0 != 1",
+		"Items.slice[*]: 1 more elements differ",
+		"Items: <stopped after MaxDiffPerPath=2> != <stopped after MaxDiffPerPath=2>",
+		"Other: 1 != 2",
+	}
+	diff = deep.Equal(a, b)
+	if len(diff) != len(want) {
+		t.Fatalf("SampleElementDiffs: got %d diffs, expected %d: %v", len(diff), len(want), diff)
+	}
+	for i := range want {
+		if diff[i] != want[i] {
+			t.Errorf("SampleElementDiffs: got %q, expected %q", diff[i], want[i])
+		}
+	}
+}
+
+func TestMaxDiffUnlimited(t *testing.T) {
+	defer func(n int) { deep.MaxDiff = n }(deep.MaxDiff)
+	a := make([]int, 1000)
+	b := make([]int, 1000)
+	for i := range b {
+		b[i] = 1
+	}
+	for _, n := range []int{0, -1} {
+		deep.MaxDiff = n
+		if diff := deep.Equal(a, b); len(diff) != 1000 {
+			t.Errorf("MaxDiff=%d: got %d diffs, expected 1000", n, len(diff))
+		}
+	}
+}
@@ -0,0 +1,218 @@
+// Package deephtml compares HTML documents with deep semantics.
+//
+// It is a separate module because HTML is parsed with golang.org/x/net/html,
+// and package deep has no dependencies.
+package deephtml
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/go-test/deep"
+	"golang.org/x/net/html"
+)
+
+// Equal compares HTML documents or fragments a and b and returns a list of
+// differences, or nil if there are none, like deep.Equal(a, b, flags...). The
+// documents are parsed like a browser parses them, with golang.org/x/net/html,
+// so implied elements like <html>, <body>, and </p> are added and misnested
+// elements are reparented. The DOM trees are compared ignoring attribute
+// order, comments, and insignificant whitespace: text is trimmed and runs of
+// whitespace are collapsed to one space, except in <pre> and <textarea>.
+// Attributes in ignoreAttrs, like "nonce", are not compared. Differences are
+// reported with CSS-selector-like paths, like:
+//
+//	html > body > div#main > p:nth-of-type(2)[class]: intro != outro
+//	html > body > h1 > #text: Hello != Goodbye
+//
+// Diffs are formatted and limited like deep.Equal: deep.MaxDiff, redaction,
+// and so on apply. An error is returned if either document cannot be read.
+func Equal(a, b string, ignoreAttrs ...string) ([]string, error) {
+	aRoot, err := parse(strings.NewReader(a), ignoreAttrs)
+	if err != nil {
+		return nil, err
+	}
+	bRoot, err := parse(strings.NewReader(b), ignoreAttrs)
+	if err != nil {
+		return nil, err
+	}
+	c := &comparer{db: deep.NewDiffBuilder()}
+	c.children("", aRoot.children, bRoot.children)
+	return c.db.Strings(), nil
+}
+
+// node is an element or text node of a DOM tree.
+type node struct {
+	tag      string // empty for text nodes
+	text     string
+	attrs    map[string]string
+	children []*node
+}
+
+// parse returns the root of the DOM tree of HTML document r.
+func parse(r io.Reader, ignoreAttrs []string) (*node, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	ignore := map[string]bool{}
+	for _, attr := range ignoreAttrs {
+		ignore[strings.ToLower(attr)] = true
+	}
+	root := &node{}
+	build(root, doc, ignore, false)
+	return root, nil
+}
+
+// build adds the element and text children of h to n. Whitespace in text is
+// collapsed unless pre is true.
+func build(n *node, h *html.Node, ignore map[string]bool, pre bool) {
+	for hc := h.FirstChild; hc != nil; hc = hc.NextSibling {
+		switch hc.Type {
+		case html.ElementNode:
+			e := &node{tag: hc.Data, attrs: map[string]string{}}
+			for _, attr := range hc.Attr {
+				name := attr.Key
+				if attr.Namespace != "" {
+					name = attr.Namespace + ":" + name
+				}
+				if !ignore[name] {
+					e.attrs[name] = attr.Val
+				}
+			}
+			n.children = append(n.children, e)
+			build(e, hc, ignore, pre || hc.Data == "pre" || hc.Data == "textarea")
+		case html.TextNode:
+			text, sep := hc.Data, ""
+			if !pre {
+				text, sep = strings.Join(strings.Fields(text), " "), " "
+			}
+			if text == "" {
+				continue
+			}
+			// Merge adjacent text, e.g. text split by a comment
+			if k := len(n.children); k > 0 && n.children[k-1].tag == "" {
+				n.children[k-1].text += sep + text
+			} else {
+				n.children = append(n.children, &node{text: text})
+			}
+		}
+	}
+}
+
+// comparer compares DOM trees, saving diffs in a deep.DiffBuilder with the
+// CSS-selector-like path of each diff as its only path element.
+type comparer struct {
+	db *deep.DiffBuilder
+}
+
+// add adds a diff at path and returns false if deep.MaxDiff is reached.
+func (c *comparer) add(path string, a, b interface{}) bool {
+	c.db.Push(path)
+	defer c.db.Pop()
+	return c.db.Add(a, b)
+}
+
+// children compares the child nodes of the element at path.
+func (c *comparer) children(path string, a, b []*node) bool {
+	n := len(a)
+	if len(b) > n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		ok := true
+		switch {
+		case i >= len(b):
+			ok = c.add(childPath(path, a, i), a[i], "<no node>")
+		case i >= len(a):
+			ok = c.add(childPath(path, b, i), "<no node>", b[i])
+		case a[i].tag != b[i].tag:
+			ok = c.add(join(path, fmt.Sprintf(":nth-child(%d)", i+1)), a[i], b[i])
+		case a[i].tag == "":
+			if a[i].text != b[i].text {
+				ok = c.add(childPath(path, a, i), a[i].text, b[i].text)
+			}
+		default:
+			ok = c.element(childPath(path, a, i), a[i], b[i])
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// element compares elements a and b which have the same tag.
+func (c *comparer) element(path string, a, b *node) bool {
+	names := []string{}
+	for name := range a.attrs {
+		names = append(names, name)
+	}
+	for name := range b.attrs {
+		if _, ok := a.attrs[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		aVal, aOK := a.attrs[name]
+		bVal, bOK := b.attrs[name]
+		ok := true
+		switch {
+		case !bOK:
+			ok = c.add(path+"["+name+"]", aVal, "<no attribute>")
+		case !aOK:
+			ok = c.add(path+"["+name+"]", "<no attribute>", bVal)
+		case aVal != bVal:
+			ok = c.add(path+"["+name+"]", aVal, bVal)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return c.children(path, a.children, b.children)
+}
+
+// childPath returns the CSS-selector-like path of nodes[i] in the element at
+// path: the tag, plus #id if set, plus :nth-of-type(n) if the tag is not unique
+// among the siblings. Text nodes are #text.
+func childPath(path string, nodes []*node, i int) string {
+	n := nodes[i]
+	if n.tag == "" {
+		return join(path, "#text")
+	}
+	sel := n.tag
+	if id, ok := n.attrs["id"]; ok {
+		sel += "#" + id
+	}
+	nth, count := 0, 0
+	for j := range nodes {
+		if nodes[j].tag == n.tag {
+			count++
+			if j <= i {
+				nth++
+			}
+		}
+	}
+	if count > 1 {
+		sel += fmt.Sprintf(":nth-of-type(%d)", nth)
+	}
+	return join(path, sel)
+}
+
+func join(path, sel string) string {
+	if path == "" {
+		return sel
+	}
+	return path + " > " + sel
+}
+
+// String returns the node as it is printed in diffs, like <div> or "text".
+func (n *node) String() string {
+	if n.tag == "" {
+		return fmt.Sprintf("%q", n.text)
+	}
+	return "<" + n.tag + ">"
+}
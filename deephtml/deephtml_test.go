@@ -0,0 +1,136 @@
+package deephtml_test
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/go-test/deep/deephtml"
+)
+
+func TestEqual(t *testing.T) {
+	a := `<!DOCTYPE html>
+<html>
+  <body>
+    <h1 class="title" id="top">Hello,   world</h1>
+    <!-- comment -->
+    <p>one<br>two</p>
+  </body>
+</html>`
+	b := `<html><body><h1 id="top" class="title">Hello, world</h1><p>one<br/>two</p></body></html>`
+	diff, err := deephtml.Equal(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+
+	a = `<div id="main"><p class="intro">Hi</p><p nonce="1">Bye</p></div>`
+	b = `<div id="main"><p class="outro">Hi</p><p nonce="2">Bye!</p><span></span></div>`
+	diff, err = deephtml.Equal(a, b, "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{
+		"html > body > div#main > p:nth-of-type(1)[class]: intro != outro",
+		"html > body > div#main > p:nth-of-type(2) > #text: Bye != Bye!",
+		"html > body > div#main > span: <no node> != <span>",
+	}
+	if len(diff) != len(expect) {
+		t.Fatalf("expected %d diff, got %d: %s", len(expect), len(diff), diff)
+	}
+	for i := range expect {
+		if diff[i] != expect[i] {
+			t.Errorf("got '%s', expected '%s'", diff[i], expect[i])
+		}
+	}
+
+	// Different elements
+	diff, err = deephtml.Equal(`<div><b>x</b></div>`, `<div><i>x</i></div>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "html > body > div > :nth-child(1): <b> != <i>" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+}
+
+func TestEqualParsing(t *testing.T) {
+	tests := []struct {
+		a, b   string
+		expect []string
+	}{
+		// Raw text in script is not parsed as tags
+		{
+			`<script>if (a < b && c) {}</script>`,
+			`<script>if (a < b && c) { x() }</script>`,
+			[]string{`html > head > script > #text: if (a < b && c) {} != if (a < b && c) { x() }`},
+		},
+		// Void element with a boolean attribute
+		{
+			`<input disabled><p>x</p>`,
+			`<input><p>x</p>`,
+			[]string{`html > body > input[disabled]:  != <no attribute>`},
+		},
+		// Implied </p>
+		{
+			`<p>a<p>b`,
+			`<p>a</p><p>c</p>`,
+			[]string{`html > body > p:nth-of-type(2) > #text: b != c`},
+		},
+		// Implied </li>
+		{
+			`<ul><li>a<li>b</ul>`,
+			`<ul><li>a</li><li>c</li></ul>`,
+			[]string{`html > body > ul > li:nth-of-type(2) > #text: b != c`},
+		},
+		// Misnested elements are reparented
+		{
+			`<b><p>x</b></p>`,
+			`<b></b><p><b>x</b></p>`,
+			nil,
+		},
+		// Whitespace is kept in pre
+		{
+			"<pre>a  b\nc</pre>",
+			`<pre>a b c</pre>`,
+			[]string{"html > body > pre > #text: a  b\nc != a b c"},
+		},
+		{
+			"<pre>\nx</pre>",
+			"<pre>x</pre>",
+			nil,
+		},
+	}
+	for _, test := range tests {
+		diff, err := deephtml.Equal(test.a, test.b)
+		if err != nil {
+			t.Errorf("%s: %s", test.a, err)
+			continue
+		}
+		if len(diff) != len(test.expect) {
+			t.Errorf("%s: expected %d diff, got %d: %q", test.a, len(test.expect), len(diff), diff)
+			continue
+		}
+		for i := range test.expect {
+			if diff[i] != test.expect[i] {
+				t.Errorf("got '%s', expected '%s'", diff[i], test.expect[i])
+			}
+		}
+	}
+}
+
+func TestEqualMaxDiff(t *testing.T) {
+	defer func(n int) { deep.MaxDiff = n }(deep.MaxDiff)
+	deep.MaxDiff = 2
+	diff, err := deephtml.Equal(`<p>a</p><p>b</p><p>c</p>`, `<p>x</p><p>y</p><p>z</p>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != 2 {
+		t.Errorf("expected 2 diff, got %d: %s", len(diff), diff)
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/go-test/deep/deephtml
+
+go 1.16
+
+require (
+	github.com/go-test/deep v1.1.1
+	golang.org/x/net v0.0.0-20210405180319-a5a99cb37ef4
+)
+
+replace github.com/go-test/deep => ../
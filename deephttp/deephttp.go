@@ -0,0 +1,126 @@
+// Package deephttp compares HTTP requests and responses with deep.Equal.
+//
+// Requests and responses are compared semantically: header names are
+// canonicalized, header values and URL query parameters are compared without
+// order, volatile headers like Date are ignored, and JSON bodies are compared
+// as JSON documents, so recorded traffic can be compared without scrubbing.
+package deephttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-test/deep"
+)
+
+// IgnoreHeaders are the headers that are ignored because they usually differ
+// between otherwise equal requests or responses.
+var IgnoreHeaders = []string{"Age", "Content-Length", "Date", "Expires", "X-Request-Id"}
+
+// request is the part of an http.Request that is compared.
+type request struct {
+	Method string
+	URL    *url.URL
+	Host   string
+	Header http.Header
+	Body   interface{}
+}
+
+// response is the part of an http.Response that is compared.
+type response struct {
+	StatusCode int
+	Header     http.Header
+	Body       interface{}
+}
+
+// EqualRequests compares requests a and b by method, URL, host, headers, and
+// body, and returns a list of differences, or nil if there are none, like
+// deep.Equal(a, b, flags...). Differences are reported by part, like
+// "Method: GET != POST", "Header.Header[Accept]: [a] != [b]", or
+// "Body.map[name]: foo != bar". The bodies are read and replaced, so the
+// requests can still be used. An error is returned if a body cannot be read.
+func EqualRequests(a, b *http.Request, flags ...interface{}) ([]string, error) {
+	var reqs [2]request
+	for i, r := range []*http.Request{a, b} {
+		body, err := readBody(&r.Body, r.Header)
+		if err != nil {
+			return nil, err
+		}
+		reqs[i] = request{
+			Method: r.Method,
+			URL:    r.URL,
+			Host:   r.Host,
+			Header: header(r.Header),
+			Body:   body,
+		}
+	}
+	return deep.Equal(reqs[0], reqs[1], withJSONBody(flags)...), nil
+}
+
+// EqualResponses compares responses a and b by status code, headers, and
+// body, and returns a list of differences, or nil if there are none, like
+// EqualRequests.
+func EqualResponses(a, b *http.Response, flags ...interface{}) ([]string, error) {
+	var resps [2]response
+	for i, r := range []*http.Response{a, b} {
+		body, err := readBody(&r.Body, r.Header)
+		if err != nil {
+			return nil, err
+		}
+		resps[i] = response{
+			StatusCode: r.StatusCode,
+			Header:     header(r.Header),
+			Body:       body,
+		}
+	}
+	return deep.Equal(resps[0], resps[1], withJSONBody(flags)...), nil
+}
+
+// withJSONBody returns a copy of flags with jsonBody, so the caller's slice is
+// not written if it has spare capacity.
+func withJSONBody(flags []interface{}) []interface{} {
+	return append(append(make([]interface{}, 0, len(flags)+1), flags...), jsonBody)
+}
+
+// jsonBody is the flag that compares JSON bodies as JSON documents.
+var jsonBody = deep.JSONType(json.RawMessage(nil))
+
+// header returns a copy of h without IgnoreHeaders.
+func header(h http.Header) http.Header {
+	h = h.Clone()
+	for _, k := range IgnoreHeaders {
+		h.Del(k)
+	}
+	return h
+}
+
+// readBody reads and replaces *body, and returns it as a json.RawMessage if
+// h has a JSON Content-Type, else as a string.
+func readBody(body *io.ReadCloser, h http.Header) (interface{}, error) {
+	if *body == nil || *body == http.NoBody {
+		return "", nil
+	}
+	p, err := ioutil.ReadAll(*body)
+	(*body).Close()
+	*body = ioutil.NopCloser(bytes.NewReader(p))
+	if err != nil {
+		return nil, err
+	}
+	if isJSON(h.Get("Content-Type")) {
+		return json.RawMessage(p), nil
+	}
+	return string(p), nil
+}
+
+// isJSON returns true if contentType is JSON, like "application/json" or
+// "application/problem+json; charset=utf-8".
+func isJSON(contentType string) bool {
+	t, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (t == "application/json" || strings.HasSuffix(t, "+json"))
+}
@@ -0,0 +1,103 @@
+package deephttp_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/go-test/deep/deephttp"
+)
+
+func TestEqualRequests(t *testing.T) {
+	a := httptest.NewRequest("POST", "http://example.com/users?b=2&a=1", strings.NewReader(`{"name": "foo", "age": 1.0}`))
+	a.Header.Set("Content-Type", "application/json")
+	a.Header.Set("Date", "Mon, 01 Jan 2024 00:00:00 GMT")
+	b := httptest.NewRequest("POST", "http://example.com/users?a=1&b=2", strings.NewReader(`{"age":1,"name":"foo"}`))
+	b.Header.Set("content-type", "application/json")
+	b.Header.Set("Date", "Tue, 02 Jan 2024 00:00:00 GMT")
+
+	diff, err := deephttp.EqualRequests(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != nil {
+		t.Error(diff)
+	}
+
+	// Bodies can be read again
+	p, _ := ioutil.ReadAll(a.Body)
+	if string(p) != `{"name": "foo", "age": 1.0}` {
+		t.Errorf("body not replaced: %q", p)
+	}
+
+	c := httptest.NewRequest("PUT", "http://example.com/users?a=1&b=2", strings.NewReader(`{"age":1,"name":"bar"}`))
+	c.Header.Set("Content-Type", "application/json")
+	c.Header.Set("Accept", "text/html")
+	diff, err = deephttp.EqualRequests(b, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"Method: POST != PUT",
+		"Header.Header[Accept]: <does not have key> != [text/html]",
+		"Body.map[name]: foo != bar",
+	}
+	if len(diff) != len(want) {
+		t.Fatalf("got %d diffs, expected %d: %v", len(diff), len(want), diff)
+	}
+	for i := range want {
+		if diff[i] != want[i] {
+			t.Errorf("got %q, expected %q", diff[i], want[i])
+		}
+	}
+
+	// Flags with spare capacity are not written
+	flags := make([]interface{}, 1, 2)
+	flags[0] = deep.IgnorePaths("Method")
+	b.Body = ioutil.NopCloser(strings.NewReader(`{}`))
+	c.Body = ioutil.NopCloser(strings.NewReader(`{}`))
+	if _, err := deephttp.EqualRequests(b, c, flags...); err != nil {
+		t.Fatal(err)
+	}
+	if flags[:2][1] != nil {
+		t.Errorf("flags written: %v", flags[:2])
+	}
+}
+
+func TestEqualResponses(t *testing.T) {
+	newResponse := func(status int, body string) *http.Response {
+		w := httptest.NewRecorder()
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Date", "now")
+		w.WriteHeader(status)
+		w.WriteString(body)
+		return w.Result()
+	}
+	diff, err := deephttp.EqualResponses(newResponse(200, "ok"), newResponse(200, "ok"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != nil {
+		t.Error(diff)
+	}
+
+	diff, err = deephttp.EqualResponses(newResponse(200, "ok"), newResponse(500, "error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"StatusCode: 200 != 500",
+		"Body: ok != error",
+	}
+	if len(diff) != len(want) {
+		t.Fatalf("got %d diffs, expected %d: %v", len(diff), len(want), diff)
+	}
+	for i := range want {
+		if diff[i] != want[i] {
+			t.Errorf("got %q, expected %q", diff[i], want[i])
+		}
+	}
+}
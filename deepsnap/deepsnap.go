@@ -0,0 +1,109 @@
+// Package deepsnap provides golden file snapshots compared with deep.Equal.
+//
+// Importing this package defines the test flag -deepsnap.update, which causes
+// golden files to be written instead of compared:
+//
+//	go test ./... -deepsnap.update
+//
+// If the test package defines its own bool flag -update, like many golden file
+// tests do, it also updates deepsnap golden files.
+package deepsnap
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+var update = flag.Bool("deepsnap.update", false, "update deepsnap golden files")
+
+// updating returns true if -deepsnap.update or a bool -update flag defined by
+// the test package is true. The flag is looked up when Match is called, not
+// when this package is initialized, because the flags of the test package are
+// defined after.
+func updating() bool {
+	if *update {
+		return true
+	}
+	f := flag.Lookup("update")
+	if f == nil {
+		return false
+	}
+	g, ok := f.Value.(flag.Getter)
+	if !ok {
+		return false
+	}
+	b, ok := g.Get().(bool)
+	return ok && b
+}
+
+// Dir is the directory of golden files.
+var Dir = "testdata"
+
+// Match compares got to the golden file Dir/name.json. got is encoded as
+// indented JSON, and both it and the golden file are decoded into
+// interface{} and compared like deep.Equal(got, golden, flags...), so flags
+// like deep.IgnorePaths use JSON paths, like "map[items].slice[*].map[id]".
+// If the values are not equal, the diffs are reported with t.Error. If the
+// -deepsnap.update flag is true, the golden file is written instead. Match
+// returns true if the values are equal or the golden file was written.
+func Match(t testing.TB, name string, got interface{}, flags ...interface{}) bool {
+	t.Helper()
+	p, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		t.Errorf("deepsnap: %s: %s", name, err)
+		return false
+	}
+	p = append(p, '\n')
+	file := filepath.Join(Dir, filepath.FromSlash(name)+".json")
+
+	if updating() {
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Errorf("deepsnap: %s", err)
+			return false
+		}
+		if err := os.WriteFile(file, p, 0644); err != nil {
+			t.Errorf("deepsnap: %s", err)
+			return false
+		}
+		return true
+	}
+
+	golden, err := os.ReadFile(file)
+	if err != nil {
+		t.Errorf("deepsnap: %s (run with -deepsnap.update to write it)", err)
+		return false
+	}
+	gotDoc, err := decode(p)
+	if err != nil {
+		t.Errorf("deepsnap: %s: %s", name, err)
+		return false
+	}
+	goldenDoc, err := decode(golden)
+	if err != nil {
+		t.Errorf("deepsnap: %s: %s", file, err)
+		return false
+	}
+	if diff := deep.Equal(gotDoc, goldenDoc, flags...); diff != nil {
+		t.Errorf("deepsnap: %s does not match (run with -deepsnap.update to update it):", file)
+		for _, d := range diff {
+			t.Error(d)
+		}
+		return false
+	}
+	return true
+}
+
+// decode decodes JSON p with numbers as json.Number.
+func decode(p []byte) (interface{}, error) {
+	var v interface{}
+	d := json.NewDecoder(bytes.NewReader(p))
+	d.UseNumber()
+	err := d.Decode(&v)
+	return v, err
+}
@@ -0,0 +1,94 @@
+package deepsnap_test
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/go-test/deep/deepsnap"
+)
+
+type fakeT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *fakeT) Error(args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprint(args...))
+}
+
+// Like the -update flag of a test package with its own golden files
+var _ = flag.Bool("update", false, "update golden files")
+
+type item struct {
+	ID   int
+	Name string
+}
+
+func TestMatch(t *testing.T) {
+	defer func(d string) { deepsnap.Dir = d }(deepsnap.Dir)
+	deepsnap.Dir = t.TempDir()
+
+	// Missing golden file
+	ft := &fakeT{}
+	if deepsnap.Match(ft, "items", []item{{1, "a"}}) {
+		t.Error("Match returned true for missing golden file")
+	}
+	if len(ft.errors) != 1 {
+		t.Fatalf("got %d errors, expected 1: %v", len(ft.errors), ft.errors)
+	}
+
+	// -deepsnap.update writes it
+	flag.Set("deepsnap.update", "true")
+	ok := deepsnap.Match(t, "items", []item{{1, "a"}})
+	flag.Set("deepsnap.update", "false")
+	if !ok {
+		t.Fatal("Match returned false with -deepsnap.update")
+	}
+
+	// So does the -update flag of the test package
+	flag.Set("update", "true")
+	ok = deepsnap.Match(t, "other", []item{{2, "b"}})
+	flag.Set("update", "false")
+	if !ok {
+		t.Fatal("Match returned false with -update")
+	}
+	if _, err := os.Stat(filepath.Join(deepsnap.Dir, "other.json")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(deepsnap.Dir, "items.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	if !deepsnap.Match(t, "items", []item{{1, "a"}}) {
+		t.Error("Match returned false for golden value")
+	}
+
+	ft = &fakeT{}
+	if deepsnap.Match(ft, "items", []item{{2, "b"}}) {
+		t.Error("Match returned true for different value")
+	}
+	if len(ft.errors) != 3 {
+		t.Fatalf("got %d errors, expected 3: %v", len(ft.errors), ft.errors)
+	}
+	if ft.errors[1] != "slice[0].map[ID]: 2 != 1" {
+		t.Errorf("wrong diff: %s", ft.errors[1])
+	}
+	if ft.errors[2] != "slice[0].map[Name]: b != a" {
+		t.Errorf("wrong diff: %s", ft.errors[2])
+	}
+
+	// Flags are passed to deep.Equal
+	if !deepsnap.Match(t, "items", []item{{2, "a"}}, deep.IgnorePaths("slice[*].map[ID]")) {
+		t.Error("Match returned false with ignored path")
+	}
+}
@@ -0,0 +1,346 @@
+package deep
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Difference is one difference between two values.
+type Difference struct {
+	// Path is the path of the values, like []string{"Address", "City"}, or
+	// empty for the top-level values. Each element is printed like in diffs,
+	// like "map[foo]" or "slice[0]".
+	Path []string
+
+	// A and B are the values as printed in the diff, like "foo" and
+	// "<nil pointer>". Both are empty for summary diffs, like
+	// "slice[5..9]: 5 elements differ".
+	A, B string
+
+	// Duplicates is the number of other differences with the same values that
+	// were collapsed into this one if DedupDiffs is true.
+	Duplicates int
+
+	text     string      // if a summary
+	note     string      // printed before the values, like "bytes differ at offset 2"
+	wantGot  bool        // print like "want A, got B"; see EqualWantGot
+	category category    // for Diff.Stats
+	a, b     interface{} // values or markers, like marker("<nil pointer>")
+}
+
+// String returns the difference as it is returned by Equal, like
+// "Address.City: foo != bar".
+func (d Difference) String() string {
+	if d.text != "" {
+		return d.text
+	}
+	if len(d.Path) == 0 && PathRoot == "" {
+		return d.values() + duplicates(d.Duplicates)
+	}
+	return joinPath(d.Path) + ": " + d.values() + duplicates(d.Duplicates)
+}
+
+// values returns the difference without its path, like "foo != bar".
+func (d Difference) values() string {
+	if d.text != "" {
+		return strings.TrimPrefix(d.text, joinPath(d.Path)+": ")
+	}
+	values := fmt.Sprintf("%s != %s", d.A, d.B)
+	if d.wantGot {
+		values = fmt.Sprintf("want %s, got %s", d.A, d.B)
+	}
+	if d.note != "" {
+		values = d.note + ": " + values
+	}
+	return values
+}
+
+// joinPath returns path as it is printed in diffs; see PathSeparator, PathRoot,
+// and CompactIndexes.
+func joinPath(path []string) string {
+	var b strings.Builder
+	b.WriteString(PathRoot)
+	for _, elem := range path {
+		if CompactIndexes {
+			if i := indexPrefix(elem); i > 0 {
+				b.WriteString(elem[i:]) // like "[2]"
+				continue
+			}
+		}
+		if b.Len() > 0 {
+			b.WriteString(PathSeparator)
+		}
+		b.WriteString(elem)
+	}
+	return b.String()
+}
+
+// indexPrefix returns the length of the kind of path element elem, like 5 for
+// "slice[2]", or 0 if it is not a slice, array, or map element.
+func indexPrefix(elem string) int {
+	if !strings.HasSuffix(elem, "]") {
+		return 0
+	}
+	for _, kind := range []string{"slice", "array", "map"} {
+		if strings.HasPrefix(elem, kind+"[") {
+			return len(kind)
+		}
+	}
+	return 0
+}
+
+// JSONPath returns the path of the difference in JSONPath syntax, like
+// `$.Items[2].Name` or `$.Labels["key with spaces"]`, which is not ambiguous
+// when map keys contain dots or brackets, and which tools like jq can use.
+// Struct fields that are identifiers are printed like ".Name", slice and array
+// indexes like "[2]", and map keys and other path elements as quoted JSON
+// strings, like `["foo"]`. A range of elements, like "slice[5..9]", is
+// printed like "[5:10]".
+func (d Difference) JSONPath() string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, elem := range d.Path {
+		b.WriteString(jsonPathElem(elem))
+	}
+	return b.String()
+}
+
+// jsonPathElem returns diff path element elem in JSONPath syntax.
+func jsonPathElem(elem string) string {
+	for _, prefix := range []string{"slice[", "array["} {
+		if !strings.HasPrefix(elem, prefix) || !strings.HasSuffix(elem, "]") {
+			continue
+		}
+		index := elem[len(prefix) : len(elem)-1]
+		if index == "*" {
+			return "[*]"
+		}
+		if i, err := strconv.Atoi(index); err == nil {
+			return "[" + strconv.Itoa(i) + "]"
+		}
+		if r := strings.SplitN(index, "..", 2); len(r) == 2 {
+			start, err1 := strconv.Atoi(r[0])
+			end, err2 := strconv.Atoi(r[1])
+			if err1 == nil && err2 == nil {
+				return fmt.Sprintf("[%d:%d]", start, end+1)
+			}
+		}
+	}
+	if strings.HasPrefix(elem, "map[") && strings.HasSuffix(elem, "]") {
+		return "[" + jsonQuote(elem[len("map["):len(elem)-1]) + "]"
+	}
+	if isIdentifier(elem) {
+		return "." + elem
+	}
+	return "[" + jsonQuote(elem) + "]"
+}
+
+// isIdentifier returns true if s is a Go or JavaScript style identifier.
+func isIdentifier(s string) bool {
+	for i, r := range s {
+		if !(r == '_' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r))) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// jsonQuote returns s as a JSON string, like "\"foo\"".
+func jsonQuote(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s) // cannot fail for a string
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// Diff is the structured result of Compare.
+type Diff struct {
+	// Differences are the differences, in the same order as Equal returns them.
+	Differences []Difference
+
+	warnings []Warning
+}
+
+// Warning is an error that did not stop comparing, like ErrNotHandled, and the
+// path where it occurred; see Diff.Warnings.
+type Warning struct {
+	// Path is the path where the error occurred, like Difference.Path.
+	Path []string
+
+	// Err is the error, like ErrNotHandled.
+	Err error
+}
+
+// String returns the warning like "Foo.Bar: cannot compare the reflect.Kind".
+func (w Warning) String() string {
+	if len(w.Path) == 0 && PathRoot == "" {
+		return w.Err.Error()
+	}
+	return joinPath(w.Path) + ": " + w.Err.Error()
+}
+
+// Warnings returns the errors that occurred while comparing but did not stop
+// it, in order, like ErrNotHandled for a func or channel field that was not
+// compared, or ErrMaxRecursion when MaxDepth was reached. They are logged, too,
+// if LogErrors is true or ErrorLogger is set. Warnings are not differences:
+// values can be equal even if there are warnings.
+func (d Diff) Warnings() []Warning {
+	return d.warnings
+}
+
+// Compare compares a and b like Equal and returns the differences as a
+// structured Diff, which has methods to query them. Diff.Strings returns the
+// same diffs as Equal.
+func Compare(a, b interface{}, flags ...interface{}) Diff {
+	c := newCmp(flags)
+	defer c.release()
+	c.compareValues(a, b)
+	c.dedup()
+	return Diff{Differences: c.details, warnings: c.warnings}
+}
+
+// Len returns the number of differences.
+func (d Diff) Len() int {
+	return len(d.Differences)
+}
+
+// Strings returns the differences as they are returned by Equal, or nil if
+// there are none.
+func (d Diff) Strings() []string {
+	if len(d.Differences) == 0 {
+		return nil
+	}
+	s := make([]string, len(d.Differences))
+	for i := range d.Differences {
+		s[i] = d.Differences[i].String()
+	}
+	return s
+}
+
+// GroupByTopLevel returns the differences grouped by the first element of
+// their paths, like "Status" for "Status.Conditions.slice[0].Reason", which
+// shows which parts of large values differ. Differences between top-level
+// values are grouped by "". The number of differences in a group is the Len
+// of its Diff.
+func (d Diff) GroupByTopLevel() map[string]Diff {
+	groups := map[string]Diff{}
+	for _, diff := range d.Differences {
+		top := ""
+		if len(diff.Path) > 0 {
+			top = diff.Path[0]
+		}
+		g := groups[top]
+		g.Differences = append(g.Differences, diff)
+		groups[top] = g
+	}
+	return groups
+}
+
+// DiffGroup is a group of differences with the same path prefix; see
+// Diff.GroupByPrefix.
+type DiffGroup struct {
+	// Prefix is the first element of the paths, like "Status", or empty for
+	// differences between top-level values.
+	Prefix string
+
+	// Diff are the differences in the group.
+	Diff Diff
+}
+
+// GroupByPrefix returns the differences grouped by the first element of their
+// paths, like GroupByTopLevel, but in order of the first difference in each
+// group, so the groups are printed deterministically.
+func (d Diff) GroupByPrefix() []DiffGroup {
+	var groups []DiffGroup
+	index := map[string]int{}
+	for _, diff := range d.Differences {
+		prefix := ""
+		if len(diff.Path) > 0 {
+			prefix = diff.Path[0]
+		}
+		i, ok := index[prefix]
+		if !ok {
+			i = len(groups)
+			index[prefix] = i
+			groups = append(groups, DiffGroup{Prefix: prefix})
+		}
+		groups[i].Diff.Differences = append(groups[i].Diff.Differences, diff)
+	}
+	return groups
+}
+
+// Tree returns the differences printed as a tree of their paths, with one path
+// element per line, indented two spaces per level, and the values after the
+// last element, like:
+//
+//	Status
+//	  Phase: Running != Pending
+//	  Ready
+//	    slice[0]: true != false
+//
+// Differences are grouped like GroupByPrefix, and elements shared by
+// consecutive differences in a group are printed once, which makes structs
+// with many differing nested fields easier to scan.
+func (d Diff) Tree() string {
+	var b strings.Builder
+	for _, g := range d.GroupByPrefix() {
+		var prev []string
+		for _, diff := range g.Diff.Differences {
+			path := diff.Path
+			if len(path) == 0 {
+				b.WriteString(diff.values() + "\n")
+				continue
+			}
+			shared := 0
+			for shared < len(prev) && shared < len(path)-1 && prev[shared] == path[shared] {
+				shared++
+			}
+			for i := shared; i < len(path); i++ {
+				b.WriteString(strings.Repeat("  ", i) + path[i])
+				if i < len(path)-1 {
+					b.WriteString("\n")
+				}
+			}
+			b.WriteString(": " + diff.values() + "\n")
+			prev = path
+		}
+	}
+	return b.String()
+}
+
+// Filter returns the differences with paths that match pathGlob or are below
+// a path that matches it. pathGlob is a path like "Status" or "Spec.map[*]",
+// where "*" matches any part of one path element; see Redact. For example,
+// "Status" matches "Status" and "Status.Phase".
+func (d Diff) Filter(pathGlob string) Diff {
+	glob := strings.Split(pathGlob, ".")
+	var f Diff
+	for _, diff := range d.Differences {
+		if len(diff.Path) >= len(glob) && matchPath(pathGlob, diff.Path[:len(glob)]) {
+			f.Differences = append(f.Differences, diff)
+		}
+	}
+	return f
+}
+
+// HasPath returns true if there is a difference at path, like "Status.Phase".
+func (d Diff) HasPath(path string) bool {
+	_, ok := d.AtPath(path)
+	return ok
+}
+
+// AtPath returns the first difference at path, like "Status.Phase", and true,
+// or false if there is no difference at path.
+func (d Diff) AtPath(path string) (Difference, bool) {
+	for _, diff := range d.Differences {
+		if strings.Join(diff.Path, ".") == path {
+			return diff, true
+		}
+	}
+	return Difference{}, false
+}
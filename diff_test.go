@@ -0,0 +1,207 @@
+package deep_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+type apiObject struct {
+	Name   string
+	Spec   map[string]int
+	Status apiStatus
+}
+
+type apiStatus struct {
+	Phase string
+	Ready []bool
+}
+
+func TestCompare(t *testing.T) {
+	a := apiObject{"foo", map[string]int{"replicas": 1}, apiStatus{"Running", []bool{true, true}}}
+	b := apiObject{"foo", map[string]int{"replicas": 2}, apiStatus{"Pending", []bool{false, false}}}
+	d := deep.Compare(a, b)
+	if diff := deep.Equal(d.Strings(), deep.Equal(a, b)); diff != nil {
+		t.Error(diff)
+	}
+	if d.Len() != 4 {
+		t.Fatalf("expected 4 differences, got %d: %s", d.Len(), d.Strings())
+	}
+	expect := deep.Difference{Path: []string{"Spec", "map[replicas]"}, A: "1", B: "2"}
+	if diff := deep.Equal(d.Differences[0], expect); diff != nil {
+		t.Error(diff)
+	}
+
+	if d := deep.Compare(a, a); d.Len() != 0 || d.Strings() != nil {
+		t.Errorf("expected no differences, got %s", d.Strings())
+	}
+	if d := deep.Compare(1, 2); d.Differences[0].String() != "1 != 2" {
+		t.Errorf("wrong difference: %s", d.Differences[0])
+	}
+}
+
+func TestGroupByTopLevel(t *testing.T) {
+	a := apiObject{"foo", map[string]int{"replicas": 1}, apiStatus{"Running", []bool{true, true}}}
+	b := apiObject{"bar", map[string]int{"replicas": 1}, apiStatus{"Pending", []bool{false, false}}}
+	groups := deep.Compare(a, b).GroupByTopLevel()
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %d: %v", len(groups), groups)
+	}
+	if groups["Name"].Len() != 1 {
+		t.Errorf("expected 1 Name difference, got %d", groups["Name"].Len())
+	}
+	if groups["Status"].Len() != 3 {
+		t.Errorf("expected 3 Status differences, got %d", groups["Status"].Len())
+	}
+	if s := groups["Status"].Strings(); s[0] != "Status.Phase: Running != Pending" {
+		t.Errorf("wrong difference: %s", s[0])
+	}
+
+	groups = deep.Compare(1, 2).GroupByTopLevel()
+	if groups[""].Len() != 1 {
+		t.Errorf("expected 1 top-level difference, got %v", groups)
+	}
+}
+
+func TestDiffFilter(t *testing.T) {
+	a := apiObject{"foo", map[string]int{"replicas": 1, "port": 80}, apiStatus{"Running", []bool{true, true}}}
+	b := apiObject{"bar", map[string]int{"replicas": 2, "port": 81}, apiStatus{"Pending", []bool{false, true}}}
+	d := deep.Compare(a, b)
+
+	if f := d.Filter("Status"); f.Len() != 2 {
+		t.Errorf("expected 2 Status differences, got %d: %s", f.Len(), f.Strings())
+	}
+	if f := d.Filter("Spec.map[*]"); f.Len() != 2 {
+		t.Errorf("expected 2 Spec differences, got %d: %s", f.Len(), f.Strings())
+	}
+	if f := d.Filter("Status.*.slice[0]"); f.Len() != 1 || f.Differences[0].String() != "Status.Ready.slice[0]: true != false" {
+		t.Errorf("wrong differences: %s", f.Strings())
+	}
+	if f := d.Filter("Nope"); f.Len() != 0 {
+		t.Errorf("expected 0 differences, got %s", f.Strings())
+	}
+
+	if !d.HasPath("Status.Phase") || d.HasPath("Status") {
+		t.Error("wrong HasPath")
+	}
+	diff, ok := d.AtPath("Name")
+	if !ok {
+		t.Fatal("no difference at Name")
+	}
+	if diff.A != "foo" || diff.B != "bar" {
+		t.Errorf("wrong difference: %s", diff)
+	}
+	if _, ok := d.AtPath("Spec"); ok {
+		t.Error("difference at Spec")
+	}
+}
+
+func TestJSONPath(t *testing.T) {
+	type item struct {
+		Name string
+	}
+	type T struct {
+		Items  []item
+		Labels map[string]string
+		Grid   [2][2]int
+	}
+	a := T{
+		Items:  []item{{"a"}, {"b"}, {"c"}},
+		Labels: map[string]string{"key with spaces": "x", `a.b["c"]`: "y"},
+	}
+	b := T{
+		Items:  []item{{"a"}, {"b"}, {"d"}},
+		Labels: map[string]string{"key with spaces": "z", `a.b["c"]`: "w"},
+	}
+	b.Grid[1][0] = 1
+	d := deep.Compare(a, b)
+	want := []string{
+		`$.Items[2].Name`,
+		`$.Labels["a.b[\"c\"]"]`,
+		`$.Labels["key with spaces"]`,
+		`$.Grid[1][0]`,
+	}
+	if d.Len() != len(want) {
+		t.Fatalf("got %d differences, expected %d: %s", d.Len(), len(want), d.Strings())
+	}
+	for i, diff := range d.Differences {
+		if got := diff.JSONPath(); got != want[i] {
+			t.Errorf("got %s, expected %s", got, want[i])
+		}
+	}
+
+	if got := deep.Compare(1, 2).Differences[0].JSONPath(); got != "$" {
+		t.Errorf("got %s, expected $", got)
+	}
+	if got := (deep.Difference{Path: []string{"slice[5..9]", "(key)"}}).JSONPath(); got != `$[5:10]["(key)"]` {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestGroupByPrefix(t *testing.T) {
+	a := apiObject{"foo", map[string]int{"replicas": 1, "port": 80}, apiStatus{"Running", []bool{true, true}}}
+	b := apiObject{"bar", map[string]int{"replicas": 2, "port": 81}, apiStatus{"Pending", []bool{false, true}}}
+	d := deep.Compare(a, b)
+
+	groups := d.GroupByPrefix()
+	prefixes := []string{}
+	for _, g := range groups {
+		prefixes = append(prefixes, fmt.Sprintf("%s=%d", g.Prefix, g.Diff.Len()))
+	}
+	if diff := deep.Equal(prefixes, []string{"Name=1", "Spec=2", "Status=2"}); diff != nil {
+		t.Error(diff)
+	}
+
+	want := `Name: foo != bar
+Spec
+  map[port]: 80 != 81
+  map[replicas]: 1 != 2
+Status
+  Phase: Running != Pending
+  Ready
+    slice[0]: true != false
+`
+	if got := d.Tree(); got != want {
+		t.Errorf("got:\n%s\nexpected:\n%s", got, want)
+	}
+
+	if got := deep.Compare(1, 2).Tree(); got != "1 != 2\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	type plugin struct {
+		Name   string
+		Run    func()
+		Events chan int
+	}
+	type T struct {
+		Plugin plugin
+	}
+	a := T{plugin{"a", func() {}, make(chan int)}}
+	b := T{plugin{"a", func() {}, make(chan int)}}
+	d := deep.Compare(a, b)
+	if d.Len() != 0 {
+		t.Errorf("got differences: %s", d.Strings())
+	}
+	want := []string{
+		"Plugin.Run: cannot compare the reflect.Kind",
+		"Plugin.Events: cannot compare the reflect.Kind",
+	}
+	w := d.Warnings()
+	if len(w) != len(want) {
+		t.Fatalf("got %d warnings, expected %d: %v", len(w), len(want), w)
+	}
+	for i := range want {
+		if w[i].String() != want[i] || !errors.Is(w[i].Err, deep.ErrNotHandled) {
+			t.Errorf("got %q, expected %q", w[i], want[i])
+		}
+	}
+
+	if w := deep.Compare(1, 2).Warnings(); w != nil {
+		t.Errorf("got warnings: %v", w)
+	}
+}
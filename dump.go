@@ -0,0 +1,91 @@
+package deep
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Dump returns v printed as Equal sees it: one line per value, indented by
+// level and named like diff paths, with struct fields in order and map keys
+// sorted, so two dumps can be compared line by line and each line matches a
+// diff path. For example:
+//
+//	Name: foo
+//	Emails:
+//	  slice[0]: foo@example.com
+//	Address:
+//	  City: NYC
+//
+// Values are walked like Walk. Values are redacted like in diffs if their field
+// has the tag `deep:"redact"` or if flags include RedactTypes or Redact, and
+// values at IgnorePaths or internal fields of FLAG_GENERATED_STRUCTS are not
+// dumped. Other flags are ignored.
+func Dump(v interface{}, flags ...interface{}) string {
+	c := newCmp(flags)
+	defer c.release()
+	var b strings.Builder
+	for _, l := range dumpLines(v, c, false) {
+		if s := l.String(); s != "" {
+			b.WriteString(s + "\n")
+		}
+	}
+	return b.String()
+}
+
+// dumpLine is one line of a Dump.
+type dumpLine struct {
+	path   []string
+	value  string
+	parent bool // the next lines are its fields, keys, or elements
+}
+
+// String returns the line as it is printed, or "" for the root value if it
+// is a parent.
+func (l dumpLine) String() string {
+	if len(l.path) == 0 {
+		if l.parent {
+			return ""
+		}
+		return l.value
+	}
+	s := strings.Repeat("  ", len(l.path)-1) + l.path[len(l.path)-1] + ":"
+	if !l.parent {
+		s += " " + l.value
+	}
+	return s
+}
+
+// dumpLines returns the lines of a Dump of v with the flags of c. If
+// zeroFields is true, zero fields that Equal can skip are dumped.
+func dumpLines(v interface{}, c *cmp, zeroFields bool) []dumpLine {
+	var lines []dumpLine
+	var values []reflect.Value
+	w := walker{
+		c:          c,
+		zeroFields: zeroFields,
+		visit: func(path []string, v reflect.Value, redacted, cycle bool) bool {
+			l := dumpLine{path: append([]string(nil), path...)}
+			switch {
+			case redacted:
+				l.value = "<redacted>"
+			case cycle:
+				l.value = "<cycle>"
+			case !v.IsValid():
+				l.value = "<nil>"
+			}
+			lines = append(lines, l)
+			values = append(values, v)
+			return !redacted
+		},
+	}
+	w.walk(reflect.ValueOf(v), 0)
+	for i := range lines {
+		lines[i].parent = i+1 < len(lines) && len(lines[i+1].path) > len(lines[i].path)
+		if lines[i].value == "" && !lines[i].parent {
+			// Format only leaf values; a parent can contain itself, like a
+			// map that is a value of itself
+			lines[i].value = formatValue(values[i], false)
+		}
+	}
+	return lines
+}
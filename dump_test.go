@@ -0,0 +1,93 @@
+package deep_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+)
+
+func TestDump(t *testing.T) {
+	type Address struct {
+		City string
+	}
+	type User struct {
+		Name     string
+		Password string `deep:"redact"`
+		Emails   []string
+		Address  *Address
+		Tags     map[string]int
+		Created  time.Time
+		Manager  *User
+		Ignored  string `deep:"-"`
+	}
+	u := User{
+		Name:     "foo",
+		Password: "hunter2",
+		Emails:   []string{"a@x", "b@x"},
+		Address:  &Address{City: "NYC"},
+		Tags:     map[string]int{"z": 1, "a": 2},
+		Created:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Ignored:  "x",
+	}
+	expect := `Name: foo
+Password: <redacted>
+Emails:
+  slice[0]: a@x
+  slice[1]: b@x
+Address:
+  City: NYC
+Tags:
+  map[a]: 2
+  map[z]: 1
+Created: 2024-01-01 00:00:00 +0000 UTC
+Manager: <nil>
+`
+	if got := deep.Dump(u); got != expect {
+		t.Errorf("got:\n%s\nexpected:\n%s", got, expect)
+	}
+
+	expect = `Name: <redacted>
+Password: <redacted>
+Emails:
+  slice[0]: a@x
+  slice[1]: b@x
+Address: <redacted>
+Tags:
+  map[a]: 2
+  map[z]: 1
+Created: 2024-01-01 00:00:00 +0000 UTC
+Manager: <nil>
+`
+	if got := deep.Dump(&u, deep.Redact("Name", "Address")); got != expect {
+		t.Errorf("got:\n%s\nexpected:\n%s", got, expect)
+	}
+
+	// Scalars and empty values
+	if got := deep.Dump(1); got != "1\n" {
+		t.Errorf("got %q", got)
+	}
+	if got := deep.Dump(User{}); got != "Name: \nPassword: <redacted>\nEmails: []\nAddress: <nil>\nTags: map[]\nCreated: 0001-01-01 00:00:00 +0000 UTC\nManager: <nil>\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestDumpCycle(t *testing.T) {
+	m := map[string]interface{}{"a": 1}
+	m["self"] = m
+	expect := "map[a]: 1\nmap[self]: <cycle>\n"
+	if got := deep.Dump(m); got != expect {
+		t.Errorf("got %q, expected %q", got, expect)
+	}
+}
+
+func TestDumpIgnorePaths(t *testing.T) {
+	type T struct {
+		Name string
+		ID   int
+	}
+	expect := "Name: a\n"
+	if got := deep.Dump(T{"a", 1}, deep.IgnorePaths("ID")); got != expect {
+		t.Errorf("got %q, expected %q", got, expect)
+	}
+}
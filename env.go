@@ -0,0 +1,34 @@
+package deep
+
+import "strings"
+
+// EqualEnv compares environment variable lists like os.Environ() or
+// exec.Cmd.Env, where each element is "KEY=VALUE". The lists are compared as
+// maps, not slices, because order does not matter. If a key occurs more than
+// once, the last value is used like os/exec does. Keys in ignore are not
+// compared. Differences are reported like Equal reports map differences, for
+// example "map[HOME]: /root != /home/user".
+func EqualEnv(a, b []string, ignore ...string) []string {
+	return Equal(envMap(a, ignore), envMap(b, ignore))
+}
+
+// envMap returns the env list as a map, last value wins, without ignored keys.
+func envMap(env []string, ignore []string) map[string]string {
+	m := make(map[string]string, len(env))
+	for _, kv := range env {
+		k, v := kv, ""
+		// Skip the first char because Windows has vars like "=C:=C:\foo"
+		if i := strings.Index(kv, "="); i == 0 {
+			if j := strings.Index(kv[1:], "="); j >= 0 {
+				k, v = kv[:j+1], kv[j+2:]
+			}
+		} else if i > 0 {
+			k, v = kv[:i], kv[i+1:]
+		}
+		m[k] = v
+	}
+	for _, k := range ignore {
+		delete(m, k)
+	}
+	return m
+}
@@ -0,0 +1,28 @@
+package deep_test
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestEqualEnv(t *testing.T) {
+	a := []string{"HOME=/root", "PATH=/bin", "EMPTY="}
+	b := []string{"PATH=/usr/bin", "EMPTY=", "HOME=/root", "PATH=/bin"}
+	diff := deep.EqualEnv(a, b)
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+
+	b = append(b, "HOME=/home/user", "PWD=/tmp", "=C:=C:\\foo")
+	diff = deep.EqualEnv(a, b, "PWD")
+	if len(diff) != 2 {
+		t.Fatalf("expected 2 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "map[HOME]: /root != /home/user" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+	if diff[1] != "map[=C:]: <does not have key> != C:\\foo" {
+		t.Errorf("wrong diff: %s", diff[1])
+	}
+}
@@ -0,0 +1,59 @@
+package deep
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Eventually calls fetch every interval and compares the value it returns
+// to want, like Equal(fetch(), want, flags...), until they are equal or
+// timeout has elapsed. It returns true if they are equal. Else, it reports
+// the diffs from the last comparison with t.Error and returns false. This is
+// useful for testing eventually consistent systems, like a value that is
+// updated asynchronously.
+func Eventually(t testing.TB, fetch func() interface{}, want interface{}, timeout, interval time.Duration, flags ...interface{}) bool {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for {
+		diff := Equal(fetch(), want, flags...)
+		if diff == nil {
+			return true
+		}
+		if time.Now().Add(interval).After(deadline) {
+			t.Errorf("not equal after %s:", timeout)
+			for _, d := range diff {
+				t.Error(d)
+			}
+			return false
+		}
+		time.Sleep(interval)
+	}
+}
+
+// WaitForConvergence calls getA and getB every interval and compares the
+// values they return, like Equal(getA(), getB(), flags...), until they are
+// equal or ctx is done. It returns the diffs observed before the values were
+// equal, one snapshot per change, which shows how the values converged: a
+// snapshot is not repeated if the diffs did not change. If ctx is done first,
+// it also returns ctx.Err().
+func WaitForConvergence(ctx context.Context, getA, getB func() interface{}, interval time.Duration, flags ...interface{}) ([][]string, error) {
+	var snapshots [][]string
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		diff := Equal(getA(), getB(), flags...)
+		if diff == nil {
+			return snapshots, nil
+		}
+		if n := len(snapshots); n == 0 || strings.Join(snapshots[n-1], "\n") != strings.Join(diff, "\n") {
+			snapshots = append(snapshots, diff)
+		}
+		select {
+		case <-ctx.Done():
+			return snapshots, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package deep_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+)
+
+// fakeT records errors instead of failing the test.
+type fakeT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Error(args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprint(args...))
+}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestEventually(t *testing.T) {
+	var n int32
+	fetch := func() interface{} {
+		return map[string]int32{"n": atomic.AddInt32(&n, 1)}
+	}
+	if !deep.Eventually(t, fetch, map[string]int32{"n": 3}, time.Second, time.Millisecond) {
+		t.Fatal("expected equal")
+	}
+	if n != 3 {
+		t.Errorf("fetched %d times, expected 3", n)
+	}
+
+	ft := &fakeT{}
+	if deep.Eventually(ft, fetch, map[string]int32{"n": 0}, 10*time.Millisecond, time.Millisecond) {
+		t.Fatal("expected not equal")
+	}
+	if len(ft.errors) != 2 {
+		t.Fatalf("expected 2 errors, got %d: %s", len(ft.errors), ft.errors)
+	}
+	if ft.errors[0] != "not equal after 10ms:" {
+		t.Errorf("wrong error: %s", ft.errors[0])
+	}
+	if ft.errors[1] != fmt.Sprintf("map[n]: %d != 0", atomic.LoadInt32(&n)) {
+		t.Errorf("wrong error: %s", ft.errors[1])
+	}
+}
+
+func TestWaitForConvergence(t *testing.T) {
+	var n int32
+	getA := func() interface{} {
+		i := atomic.AddInt32(&n, 1)
+		if i > 4 {
+			i = 4
+		}
+		return []int32{i / 2, i}
+	}
+	getB := func() interface{} {
+		return []int32{2, 4}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	snapshots, err := deep.WaitForConvergence(ctx, getA, getB, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := [][]string{
+		{"slice[0]: 0 != 2", "slice[1]: 1 != 4"},
+		{"slice[0]: 1 != 2", "slice[1]: 2 != 4"},
+		{"slice[0]: 1 != 2", "slice[1]: 3 != 4"},
+	}
+	if diff := deep.Equal(snapshots, expect); diff != nil {
+		t.Error(diff)
+	}
+
+	// Never converges
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	snapshots, err = deep.WaitForConvergence(ctx, getA, func() interface{} { return nil }, time.Millisecond)
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if len(snapshots) != 1 {
+		t.Errorf("expected 1 snapshot, got %d: %s", len(snapshots), snapshots)
+	}
+}
@@ -0,0 +1,69 @@
+package deep
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// formatRaw returns v printed like %v but without calling String or Error
+// methods, so the underlying value is printed, like "0" instead of
+// "VALUE_ZERO". Pointers are printed as addresses below the top level, like
+// %v, which also prevents infinite recursion.
+func formatRaw(v reflect.Value, depth int) string {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return "<nil>"
+	case reflect.Bool:
+		return fmt.Sprint(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fmt.Sprint(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return fmt.Sprint(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return fmt.Sprint(v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		return fmt.Sprint(v.Complex())
+	case reflect.String:
+		return v.String()
+	case reflect.Interface:
+		if v.IsNil() {
+			return "<nil>"
+		}
+		return formatRaw(v.Elem(), depth)
+	case reflect.Ptr:
+		if v.IsNil() {
+			return "<nil>"
+		}
+		if depth > 0 {
+			return fmt.Sprintf("%#x", v.Pointer())
+		}
+		switch v.Elem().Kind() {
+		case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+			return "&" + formatRaw(v.Elem(), depth+1)
+		}
+		return fmt.Sprintf("%#x", v.Pointer())
+	case reflect.Struct:
+		s := make([]string, v.NumField())
+		for i := range s {
+			s[i] = formatRaw(v.Field(i), depth+1)
+		}
+		return "{" + strings.Join(s, " ") + "}"
+	case reflect.Slice, reflect.Array:
+		s := make([]string, v.Len())
+		for i := range s {
+			s[i] = formatRaw(v.Index(i), depth+1)
+		}
+		return "[" + strings.Join(s, " ") + "]"
+	case reflect.Map:
+		s := make([]string, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			s = append(s, formatRaw(iter.Key(), depth+1)+":"+formatRaw(iter.Value(), depth+1))
+		}
+		sort.Strings(s)
+		return "map[" + strings.Join(s, " ") + "]"
+	}
+	return fmt.Sprintf("%v", v) // chan, func, unsafe pointer
+}
@@ -0,0 +1,76 @@
+package deep
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+)
+
+// FileModeMask is the mask applied to file permission bits by EqualFS. The
+// default compares all permission bits. Set it to 0700, for example, to ignore
+// group and other bits which are not meaningful on Windows.
+var FileModeMask fs.FileMode = fs.ModePerm
+
+// fsEntry is a file in a file system compared by EqualFS.
+type fsEntry struct {
+	Mode   string
+	Target string
+	SHA256 string
+}
+
+// EqualFS compares file systems a and b, like os.DirFS or fstest.MapFS, and
+// returns a list of differences, or nil if there are none. Files are compared
+// by type, permission bits masked by FileModeMask, content (SHA-256 hash), and
+// symlink target if the file system has a ReadLink(name string) (string, error)
+// method like os.DirFS in Go 1.25. Differences are reported by file path, like
+// "map[bin/run].Mode: -rw-r--r-- != -rwxr-xr-x". An error is returned if either
+// file system cannot be read.
+func EqualFS(a, b fs.FS) ([]string, error) {
+	aFiles, err := readFS(a)
+	if err != nil {
+		return nil, err
+	}
+	bFiles, err := readFS(b)
+	if err != nil {
+		return nil, err
+	}
+	return Equal(aFiles, bFiles), nil
+}
+
+// readFS returns all files in fsys keyed by path.
+func readFS(fsys fs.FS) (map[string]fsEntry, error) {
+	files := map[string]fsEntry{}
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == "." {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		mode := info.Mode() & (fs.ModeType | FileModeMask)
+		e := fsEntry{Mode: mode.String()}
+		switch {
+		case mode&fs.ModeSymlink != 0:
+			if rl, ok := fsys.(interface {
+				ReadLink(name string) (string, error)
+			}); ok {
+				if e.Target, err = rl.ReadLink(path); err != nil {
+					return err
+				}
+			}
+		case mode.IsRegular():
+			content, err := fs.ReadFile(fsys, path)
+			if err != nil {
+				return err
+			}
+			e.SHA256 = fmt.Sprintf("%x", sha256.Sum256(content))
+		}
+		files[path] = e
+		return nil
+	})
+	return files, err
+}
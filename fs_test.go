@@ -0,0 +1,88 @@
+package deep_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-test/deep"
+)
+
+func TestEqualFS(t *testing.T) {
+	a := fstest.MapFS{
+		"README":  {Data: []byte("hello"), Mode: 0644},
+		"bin/run": {Data: []byte("#!/bin/sh"), Mode: 0755},
+	}
+	b := fstest.MapFS{
+		"README":  {Data: []byte("hello"), Mode: 0644},
+		"bin/run": {Data: []byte("#!/bin/sh"), Mode: 0755},
+	}
+	diff, err := deep.EqualFS(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+
+	b["bin/run"].Mode = 0744
+	diff, err = deep.EqualFS(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "map[bin/run].Mode: -rwxr-xr-x != -rwxr--r--" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+
+	// Ignore group and other bits
+	defaultFileModeMask := deep.FileModeMask
+	deep.FileModeMask = 0700
+	defer func() { deep.FileModeMask = defaultFileModeMask }()
+	diff, err = deep.EqualFS(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+
+	// Content and missing files
+	b["README"].Data = []byte("bye")
+	delete(b, "bin/run")
+	diff, err = deep.EqualFS(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != 3 {
+		t.Fatalf("expected 3 diff, got %d: %s", len(diff), diff)
+	}
+}
+
+func TestEqualFSSymlink(t *testing.T) {
+	a, b := t.TempDir(), t.TempDir()
+	if err := os.Symlink("foo", filepath.Join(a, "link")); err != nil {
+		t.Skip("cannot create symlink:", err)
+	}
+	if err := os.Symlink("bar", filepath.Join(b, "link")); err != nil {
+		t.Fatal(err)
+	}
+	diff, err := deep.EqualFS(os.DirFS(a), os.DirFS(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := os.DirFS(a).(interface {
+		ReadLink(name string) (string, error)
+	}); !ok {
+		t.Skip("os.DirFS does not have ReadLink")
+	}
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "map[link].Target: foo != bar" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+}
@@ -0,0 +1,52 @@
+package deep
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"reflect"
+)
+
+var (
+	fileType     = reflect.TypeOf((*os.File)(nil))
+	processType  = reflect.TypeOf((*os.Process)(nil))
+	cmdType      = reflect.TypeOf((*exec.Cmd)(nil))
+	connType     = reflect.TypeOf((*net.Conn)(nil)).Elem()
+	listenerType = reflect.TypeOf((*net.Listener)(nil)).Elem()
+)
+
+// isHandle returns true if pointer type t is an OS or runtime handle; see
+// CompareHandlesByName.
+func isHandle(t reflect.Type) bool {
+	return t == fileType || t == processType || t == cmdType ||
+		t.Implements(connType) || t.Implements(listenerType)
+}
+
+// equalsHandle compares handles a and b by nil-ness and name.
+func (c *cmp) equalsHandle(a, b reflect.Value) {
+	if a.IsNil() || b.IsNil() {
+		if a.IsNil() && !b.IsNil() {
+			c.saveDiff(marker("<nil pointer>"), b.Type())
+		} else if !a.IsNil() && b.IsNil() {
+			c.saveDiff(a.Type(), marker("<nil pointer>"))
+		}
+		return
+	}
+	if !a.CanInterface() || !b.CanInterface() {
+		return
+	}
+	switch a := a.Interface().(type) {
+	case *os.File:
+		c.push("Name")
+		c.equals(reflect.ValueOf(a.Name()), reflect.ValueOf(b.Interface().(*os.File).Name()), 0)
+		c.pop()
+	case *exec.Cmd:
+		b := b.Interface().(*exec.Cmd)
+		c.push("Path")
+		c.equals(reflect.ValueOf(a.Path), reflect.ValueOf(b.Path), 0)
+		c.pop()
+		c.push("Args")
+		c.equals(reflect.ValueOf(a.Args), reflect.ValueOf(b.Args), 0)
+		c.pop()
+	}
+}
@@ -0,0 +1,54 @@
+package deep_test
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestCompareHandlesByName(t *testing.T) {
+	type Job struct {
+		Log  *os.File
+		Cmd  *exec.Cmd
+		Conn net.Conn
+	}
+	f1, err := os.Open(os.Args[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f1.Close()
+	f2, err := os.Open(os.Args[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f2.Close()
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+
+	a := Job{Log: f1, Cmd: exec.Command("ls", "-l"), Conn: c1}
+	b := Job{Log: f2, Cmd: exec.Command("ls", "-l"), Conn: c2}
+	diff := deep.Equal(a, b)
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+
+	b.Cmd = exec.Command("ls", "-a")
+	b.Conn = nil
+	diff = deep.Equal(a, b)
+	expect := []string{
+		"Cmd.Args.slice[1]: -l != -a",
+		"Conn: *net.pipe != <nil pointer>",
+	}
+	if len(diff) != len(expect) {
+		t.Fatalf("expected %d diff, got %d: %s", len(expect), len(diff), diff)
+	}
+	for i := range expect {
+		if diff[i] != expect[i] {
+			t.Errorf("got '%s', expected '%s'", diff[i], expect[i])
+		}
+	}
+}
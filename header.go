@@ -0,0 +1,109 @@
+package deep
+
+import (
+	"fmt"
+	"net/textproto"
+	"net/url"
+	"reflect"
+	"sort"
+)
+
+var (
+	urlValuesType  = reflect.TypeOf(url.Values{})
+	mimeHeaderType = reflect.TypeOf(textproto.MIMEHeader{})
+)
+
+// multiValueMap returns the name printed in paths, like "Header" in
+// "Header[Content-Type]", if t is http.Header, textproto.MIMEHeader, or
+// url.Values, else "". http.Header is matched by name so this package does
+// not import net/http.
+func multiValueMap(t reflect.Type) string {
+	switch {
+	case t == urlValuesType:
+		return "Values"
+	case t == mimeHeaderType:
+		return "Header"
+	case t.PkgPath() == "net/http" && t.Name() == "Header" && t.ConvertibleTo(mimeHeaderType):
+		return "Header"
+	}
+	return ""
+}
+
+// equalsMultiValues compares http.Header, textproto.MIMEHeader, or url.Values
+// a and b, which are map[string][]string, by key, like
+// "Header[Content-Type]: [text/plain] != [text/html]". Header keys are
+// canonicalized, like "content-type" to "Content-Type", and values are
+// compared without order unless MultiValueOrder is true.
+func (c *cmp) equalsMultiValues(a, b reflect.Value, name string) {
+	canonical := name == "Header"
+	aMap, bMap := multiValues(a, canonical), multiValues(b, canonical)
+	keys := make([]string, 0, len(aMap)+len(bMap))
+	for k := range aMap {
+		keys = append(keys, k)
+	}
+	for k := range bMap {
+		if _, ok := aMap[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		aVals, aOK := aMap[k]
+		bVals, bOK := bMap[k]
+		c.push(fmt.Sprintf("%s[%s]", name, k))
+		switch {
+		case !bOK:
+			c.saveDiff(aVals, marker("<does not have key>"))
+		case !aOK:
+			c.saveDiff(marker("<does not have key>"), bVals)
+		case !sameValues(aVals, bVals):
+			c.saveDiff(aVals, bVals)
+		}
+		c.pop()
+		if c.full() {
+			return
+		}
+	}
+}
+
+// multiValues returns map[string][]string m as a map with canonical header
+// keys, if canonical is true, merging the values of keys that differ only
+// by case.
+func multiValues(m reflect.Value, canonical bool) map[string][]string {
+	values := map[string][]string{}
+	iter := m.MapRange()
+	for iter.Next() {
+		k := iter.Key().String()
+		if canonical {
+			k = textproto.CanonicalMIMEHeaderKey(k)
+		}
+		v := iter.Value()
+		for i := 0; i < v.Len(); i++ {
+			values[k] = append(values[k], v.Index(i).String())
+		}
+		if _, ok := values[k]; !ok {
+			values[k] = []string{} // key with no values
+		}
+	}
+	return values
+}
+
+// sameValues returns true if a and b have the same values, in the same order
+// if MultiValueOrder is true.
+func sameValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	if !MultiValueOrder {
+		a = append([]string(nil), a...)
+		b = append([]string(nil), b...)
+		sort.Strings(a)
+		sort.Strings(b)
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
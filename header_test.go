@@ -0,0 +1,62 @@
+package deep_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestHTTPHeader(t *testing.T) {
+	a := http.Header{
+		"content-type": {"text/plain"}, // not canonical
+		"Accept":       {"a", "b"},
+		"X-Only-A":     {"1"},
+	}
+	b := http.Header{}
+	b.Set("Content-Type", "text/html")
+	b.Add("Accept", "b")
+	b.Add("Accept", "a")
+	b.Set("X-Only-B", "2")
+
+	diff := deep.Equal(a, b)
+	expect := []string{
+		"Header[Content-Type]: [text/plain] != [text/html]",
+		"Header[X-Only-A]: [1] != <does not have key>",
+		"Header[X-Only-B]: <does not have key> != [2]",
+	}
+	if d := deep.Equal(diff, expect); d != nil {
+		t.Error(d)
+	}
+
+	// Order of values
+	defer func(v bool) { deep.MultiValueOrder = v }(deep.MultiValueOrder)
+	deep.MultiValueOrder = true
+	diff = deep.Equal(http.Header{"Accept": {"a", "b"}}, http.Header{"Accept": {"b", "a"}})
+	if len(diff) != 1 || diff[0] != "Header[Accept]: [a b] != [b a]" {
+		t.Errorf("wrong diffs: %v", diff)
+	}
+
+	// In a struct
+	type request struct {
+		Header http.Header
+	}
+	deep.MultiValueOrder = false
+	if diff := deep.Equal(request{a}, request{a.Clone()}); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestURLValues(t *testing.T) {
+	a := url.Values{"q": {"go", "deep"}, "page": {"1"}}
+	b, _ := url.ParseQuery("q=deep&q=go&page=2&Q=x")
+	diff := deep.Equal(a, b)
+	expect := []string{
+		"Values[Q]: <does not have key> != [x]", // not canonicalized
+		"Values[page]: [1] != [2]",
+	}
+	if d := deep.Equal(diff, expect); d != nil {
+		t.Error(d)
+	}
+}
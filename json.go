@@ -0,0 +1,153 @@
+package deep
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+)
+
+// jsonType is the flag returned by JSONType.
+type jsonType struct {
+	t reflect.Type
+}
+
+// JSONType returns a flag for Equal that compares all values with the same type
+// as v, which must be a string or []byte kind like json.RawMessage, as JSON
+// documents. The documents are canonicalized and then compared like EqualJSON,
+// so differences in formatting, key order, and number notation are ignored.
+// To compare a single struct field as JSON, use the tag `deep:"json"`.
+//
+// JSONType panics if v is not a string or []byte kind.
+func JSONType(v interface{}) interface{} {
+	t := reflect.TypeOf(v)
+	if !isStringOrBytes(t) {
+		panic(fmt.Sprintf("deep: JSONType: %s is not a string or []byte kind", t))
+	}
+	return jsonType{t: t}
+}
+
+// EqualJSON compares JSON documents a and b and returns a list of differences,
+// or nil if there are none. The documents are decoded and canonicalized like
+// CanonicalJSON, then compared like Equal compares map[string]interface{}, so
+// differences are reported by path, like "map[user].map[name]: foo != bar".
+// An error is returned if either document is not valid JSON.
+func EqualJSON(a, b []byte) ([]string, error) {
+	aDoc, err := decodeJSON(a)
+	if err != nil {
+		return nil, err
+	}
+	bDoc, err := decodeJSON(b)
+	if err != nil {
+		return nil, err
+	}
+	return Equal(aDoc, bDoc), nil
+}
+
+// EqualAsJSON compares a and b as JSON: both are marshaled with json.Marshal,
+// then decoded and compared like EqualJSON with flags. Only what is encoded is
+// compared, so unexported fields and fields tagged `json:"-"` are ignored, and
+// differences are reported by JSON names, like "map[user].map[name]: foo !=
+// bar". This is useful for API contract tests. An error is returned if either
+// value cannot be marshaled.
+func EqualAsJSON(a, b interface{}, flags ...interface{}) ([]string, error) {
+	var docs [2]interface{}
+	for i, v := range []interface{}{a, b} {
+		p, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		if docs[i], err = decodeJSON(p); err != nil {
+			return nil, err
+		}
+	}
+	return Equal(docs[0], docs[1], flags...), nil
+}
+
+// CanonicalJSON returns the canonical form of JSON document p: object keys
+// are sorted, insignificant whitespace is removed, and numbers are normalized
+// so that, for example, 1, 1.0, and 1e0 are all 1. Two documents that differ
+// only in formatting have the same canonical form.
+func CanonicalJSON(p []byte) ([]byte, error) {
+	doc, err := decodeJSON(p)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc) // sorts map keys
+}
+
+// decodeJSON decodes JSON document p with numbers normalized.
+func decodeJSON(p []byte) (interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(p))
+	d.UseNumber()
+	var doc interface{}
+	if err := d.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if d.More() {
+		return nil, fmt.Errorf("invalid JSON: data after top-level value")
+	}
+	return normalizeJSON(doc), nil
+}
+
+// normalizeJSON replaces every json.Number in doc with its canonical form.
+func normalizeJSON(doc interface{}) interface{} {
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		for k := range v {
+			v[k] = normalizeJSON(v[k])
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = normalizeJSON(v[i])
+		}
+	case json.Number:
+		if f, ok := new(big.Float).SetPrec(256).SetString(string(v)); ok {
+			return json.Number(f.Text('g', -1))
+		}
+	}
+	return doc
+}
+
+func isStringOrBytes(t reflect.Type) bool {
+	return t.Kind() == reflect.String ||
+		(t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8)
+}
+
+// equalsJSON compares string or []byte values a and b as JSON documents. If
+// only one value is not valid JSON, the error is reported as a diff. If neither
+// is, the raw values are compared.
+func (c *cmp) equalsJSON(a, b reflect.Value, level int) {
+	if !isStringOrBytes(a.Type()) {
+		c.logError(ErrNotHandled)
+		c.equals(a, b, level)
+		return
+	}
+	raw := func(v reflect.Value) []byte {
+		if v.Kind() == reflect.String {
+			return []byte(v.String())
+		}
+		return v.Bytes()
+	}
+	aDoc, aErr := decodeJSON(raw(a))
+	bDoc, bErr := decodeJSON(raw(b))
+	if aErr != nil && bErr != nil {
+		if aRaw, bRaw := string(raw(a)), string(raw(b)); aRaw != bRaw {
+			c.saveDiff(aRaw, bRaw)
+		}
+		return
+	}
+	if aErr != nil || bErr != nil {
+		aVal, bVal := marker("<JSON>"), marker("<JSON>")
+		if aErr != nil {
+			aVal = marker(fmt.Sprintf("<invalid JSON: %s>", aErr))
+		}
+		if bErr != nil {
+			bVal = marker(fmt.Sprintf("<invalid JSON: %s>", bErr))
+		}
+		c.saveDiff(aVal, bVal)
+		return
+	}
+	c.equals(reflect.ValueOf(aDoc), reflect.ValueOf(bDoc), level)
+}
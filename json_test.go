@@ -0,0 +1,120 @@
+package deep_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	got, err := deep.CanonicalJSON([]byte(` { "b": [1.0, 2e0, 0.10], "a": {"y": null, "x": "s"} } `))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"a":{"x":"s","y":null},"b":[1,2,0.1]}`
+	if string(got) != expect {
+		t.Errorf("got %s, expected %s", got, expect)
+	}
+
+	if _, err := deep.CanonicalJSON([]byte(`{} {}`)); err == nil {
+		t.Error("expected error for trailing data")
+	}
+}
+
+func TestEqualJSON(t *testing.T) {
+	diff, err := deep.EqualJSON([]byte(`{"a": 1, "b": [true]}`), []byte(`{"b":[true],"a":1.0}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+
+	diff, err = deep.EqualJSON([]byte(`{"user": {"name": "foo"}}`), []byte(`{"user": {"name": "bar"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "map[user].map[name]: foo != bar" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+
+	if _, err := deep.EqualJSON([]byte(`{`), []byte(`{}`)); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestJSONTagAndType(t *testing.T) {
+	type Event struct {
+		Body    string `deep:"json"`
+		Payload json.RawMessage
+	}
+	a := Event{Body: `{"id": 1, "tags": ["x"]}`, Payload: json.RawMessage(`{"a": 1, "b": 2}`)}
+	b := Event{Body: `{"tags":["x"],"id":1}`, Payload: json.RawMessage(`{"b":2,"a":1}`)}
+
+	// Body is compared as JSON, Payload is not
+	diff := deep.Equal(a, b)
+	if len(diff) == 0 {
+		t.Fatal("no diff")
+	}
+	diff = deep.Equal(a, b, deep.JSONType(json.RawMessage{}))
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+
+	b.Body = `{"tags":["y"],"id":1}`
+	diff = deep.Equal(a, b, deep.JSONType(json.RawMessage{}))
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "Body.map[tags].slice[0]: x != y" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+
+	b.Body = `not json`
+	diff = deep.Equal(a, b, deep.JSONType(json.RawMessage{}))
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "Body: <JSON> != <invalid JSON: invalid character 'o' in literal null (expecting 'u')>" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+}
+
+func TestEqualAsJSON(t *testing.T) {
+	type user struct {
+		Name     string  `json:"name"`
+		Age      float64 `json:"age,omitempty"`
+		Password string  `json:"-"`
+		session  int
+	}
+	type apiUser struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+	a := user{Name: "foo", Age: 30, Password: "secret", session: 1}
+	b := apiUser{Name: "foo", Age: 30}
+	diff, err := deep.EqualAsJSON(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != nil {
+		t.Error(diff)
+	}
+
+	b.Name = "bar"
+	diff, err = deep.EqualAsJSON(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != 1 || diff[0] != "map[name]: foo != bar" {
+		t.Errorf("got %v", diff)
+	}
+
+	if _, err := deep.EqualAsJSON(make(chan int), b); err == nil {
+		t.Error("no error for value that cannot be marshaled")
+	}
+}
@@ -0,0 +1,34 @@
+package deep
+
+import "reflect"
+
+// kindFuncs are the registered funcs to compare values by kind.
+var kindFuncs = map[reflect.Kind]func(a, b reflect.Value) bool{
+	reflect.Complex64:     equalComplex,
+	reflect.Complex128:    equalComplex,
+	reflect.Uintptr:       func(a, b reflect.Value) bool { return a.Uint() == b.Uint() },
+	reflect.UnsafePointer: func(a, b reflect.Value) bool { return a.Pointer() == b.Pointer() },
+}
+
+func equalComplex(a, b reflect.Value) bool {
+	return a.Complex() == b.Complex()
+}
+
+// RegisterKind registers func equal to compare values of kind, which is called
+// with two values of the same type and returns true if they are equal. If not,
+// the values are reported as a diff. Funcs for complex, uintptr, and
+// unsafe.Pointer kinds are registered by default. A registered func takes
+// precedence over the built-in comparison of kind, except for pointers and
+// interfaces, which are dereferenced before comparing. This lets users handle
+// a kind added by a new Go release, which Equal otherwise reports as a diff
+// like "<cannot compare kind27>" and logs ErrUnknownKind. A nil equal
+// unregisters kind.
+//
+// RegisterKind is not safe to call concurrently with Equal.
+func RegisterKind(kind reflect.Kind, equal func(a, b reflect.Value) bool) {
+	if equal == nil {
+		delete(kindFuncs, kind)
+		return
+	}
+	kindFuncs[kind] = equal
+}
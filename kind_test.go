@@ -0,0 +1,73 @@
+package deep_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestComplexAndUintptr(t *testing.T) {
+	diff := deep.Equal(complex(1, 2), complex(1, 3))
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %v", len(diff), diff)
+	}
+	if diff[0] != "(1+2i) != (1+3i)" {
+		t.Error("wrong diff:", diff[0])
+	}
+	if diff := deep.Equal(complex64(1), complex64(1)); diff != nil {
+		t.Error(diff)
+	}
+
+	diff = deep.Equal(uintptr(1), uintptr(2))
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %v", len(diff), diff)
+	}
+	if diff[0] != "1 != 2" {
+		t.Error("wrong diff:", diff[0])
+	}
+}
+
+func TestRegisterKind(t *testing.T) {
+	deep.RegisterKind(reflect.String, func(a, b reflect.Value) bool {
+		return strings.EqualFold(a.String(), b.String())
+	})
+	defer deep.RegisterKind(reflect.String, nil)
+
+	if diff := deep.Equal("foo", "FOO"); diff != nil {
+		t.Error(diff)
+	}
+	diff := deep.Equal("foo", "bar")
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %v", len(diff), diff)
+	}
+	if diff[0] != "foo != bar" {
+		t.Error("wrong diff:", diff[0])
+	}
+
+	// Unregistered built-in string comparison
+	deep.RegisterKind(reflect.String, nil)
+	if diff := deep.Equal("foo", "FOO"); len(diff) != 1 {
+		t.Errorf("expected 1 diff, got %d: %v", len(diff), diff)
+	}
+}
+
+func TestUnknownKind(t *testing.T) {
+	// Unregister complex128 to make it unknown, like a future kind
+	deep.RegisterKind(reflect.Complex128, nil)
+	defer deep.RegisterKind(reflect.Complex128, func(a, b reflect.Value) bool {
+		return a.Complex() == b.Complex()
+	})
+
+	type T struct {
+		C complex128
+	}
+	diff := deep.Equal(T{1}, T{1})
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %v", len(diff), diff)
+	}
+	if diff[0] != "C: <cannot compare complex128> != <cannot compare complex128>" {
+		t.Error("wrong diff:", diff[0])
+	}
+}
@@ -0,0 +1,79 @@
+package deep
+
+import (
+	"reflect"
+	"sync"
+)
+
+type rLocker interface {
+	RLock()
+	RUnlock()
+}
+
+// equalsSnapshot compares the results of the Snapshot methods of pointers a
+// and b, if they have one, and returns true if it did.
+func (c *cmp) equalsSnapshot(a, b reflect.Value, level int) bool {
+	i := methodsOf(a.Type()).snapshot
+	if i < 0 {
+		return false
+	}
+	fn := a.Method(i)
+	funcType := fn.Type()
+	if funcType.NumIn() != 0 || funcType.NumOut() != 1 || funcType.Out(0) == a.Type() {
+		return false
+	}
+	aOut, aPanic := callMethod("Snapshot", fn)
+	bOut, bPanic := callMethod("Snapshot", b.Method(i))
+	if aPanic != "" || bPanic != "" {
+		var aval, bval interface{} = aPanic, bPanic
+		if aPanic == "" {
+			aval = aOut[0]
+		} else if bPanic == "" {
+			bval = bOut[0]
+		}
+		c.saveDiff(aval, bval)
+		return true
+	}
+	c.equals(aOut[0], bOut[0], level)
+	return true
+}
+
+// lock locks pointers a and b, in address order to avoid deadlocks, if they
+// can be locked and are not already locked, and returns a func that unlocks
+// them.
+func (c *cmp) lock(a, b reflect.Value) func() {
+	first, second := a, b
+	if b.Pointer() < a.Pointer() {
+		first, second = b, a
+	}
+	var unlock []func()
+	for _, v := range []reflect.Value{first, second} {
+		if c.locked[v.Pointer()] {
+			continue // same pointer or cycle
+		}
+		var lock, release func()
+		switch l := v.Interface().(type) {
+		case rLocker:
+			lock, release = l.RLock, l.RUnlock
+		case sync.Locker:
+			lock, release = l.Lock, l.Unlock
+		default:
+			continue
+		}
+		if c.locked == nil {
+			c.locked = map[uintptr]bool{}
+		}
+		p := v.Pointer()
+		c.locked[p] = true
+		lock()
+		unlock = append(unlock, func() {
+			release()
+			delete(c.locked, p)
+		})
+	}
+	return func() {
+		for i := len(unlock) - 1; i >= 0; i-- {
+			unlock[i]()
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package deep_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+type counter struct {
+	sync.Mutex
+	N int
+}
+
+type recordingLocker struct {
+	Locked bool `deep:"-"`
+	N      int
+	calls  *[]string
+}
+
+func (l *recordingLocker) Lock()   { l.Locked = true; *l.calls = append(*l.calls, "lock") }
+func (l *recordingLocker) Unlock() { l.Locked = false; *l.calls = append(*l.calls, "unlock") }
+
+type stats struct {
+	mu sync.RWMutex
+	n  int
+}
+
+type statsSnapshot struct {
+	N int
+}
+
+func (s *stats) Snapshot() statsSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return statsSnapshot{N: s.n}
+}
+
+func TestLockValues(t *testing.T) {
+	defer func(v bool) { deep.LockValues = v }(deep.LockValues)
+	deep.LockValues = true
+
+	// Embedded mutex is locked while comparing, no race with writer
+	a := &counter{N: 1}
+	b := &counter{N: 1}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			a.Lock()
+			a.N++
+			a.Unlock()
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		deep.Equal(a, b)
+	}
+	wg.Wait()
+
+	// Same pointer is locked once
+	if diff := deep.Equal(b, b); diff != nil {
+		t.Error(diff)
+	}
+
+	var calls []string
+	l1 := &recordingLocker{N: 1, calls: &calls}
+	l2 := &recordingLocker{N: 2, calls: &calls}
+	diff := deep.Equal(l1, l2)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %v", len(diff), diff)
+	}
+	if diff[0] != "N: 1 != 2" {
+		t.Error("wrong diff:", diff[0])
+	}
+	if len(calls) != 4 || calls[0] != "lock" || calls[1] != "lock" || calls[2] != "unlock" || calls[3] != "unlock" {
+		t.Errorf("wrong calls: %v", calls)
+	}
+
+	// Not locked if LockValues is false
+	deep.LockValues = false
+	calls = nil
+	deep.Equal(l1, l2)
+	if len(calls) != 0 {
+		t.Errorf("locked with LockValues false: %v", calls)
+	}
+}
+
+func TestLockValuesSnapshot(t *testing.T) {
+	defer func(v bool) { deep.LockValues = v }(deep.LockValues)
+	deep.LockValues = true
+
+	a := &stats{n: 1}
+	b := &stats{n: 2}
+	diff := deep.Equal(a, b)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %v", len(diff), diff)
+	}
+	if diff[0] != "N: 1 != 2" {
+		t.Error("wrong diff:", diff[0])
+	}
+
+	b.n = 1
+	if diff := deep.Equal(a, b); diff != nil {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,16 @@
+package deep
+
+// Logger logs errors that do not stop comparing, like ErrTypeMismatch and
+// ErrNotHandled; see ErrorLogger. path is the path where the error occurred,
+// like "Address.City", or empty for top-level values.
+type Logger interface {
+	LogError(path string, err error)
+}
+
+// LoggerFunc is a func that implements Logger.
+type LoggerFunc func(path string, err error)
+
+// LogError calls f(path, err).
+func (f LoggerFunc) LogError(path string, err error) {
+	f(path, err)
+}
@@ -0,0 +1,46 @@
+package deep
+
+// LogIgnoreKeys are the keys of log entries that EqualLogs always ignores
+// because their values change on every run.
+var LogIgnoreKeys = []string{"time", "ts", "timestamp", "caller", "source"}
+
+// EqualLogs compares structured log entries, like JSON log lines decoded into
+// maps, and returns a list of differences, or nil if there are none. Keys in
+// LogIgnoreKeys and ignoreKeys are not compared. If unordered is true, the
+// order of entries does not matter: each entry in a is paired with an equal
+// entry in b, and entries without a pair are reported as "<no match>".
+// Otherwise, entries are compared in order, like "slice[1].map[msg]: a != b".
+// On Go 1.21 and newer, slog records can be converted with SlogEntries.
+func EqualLogs(a, b []map[string]interface{}, unordered bool, ignoreKeys ...string) []string {
+	a = withoutKeys(a, ignoreKeys)
+	b = withoutKeys(b, ignoreKeys)
+	if unordered {
+		return Equal(a, b, ElementMatcher(func(x, y map[string]interface{}) bool {
+			return Equal(x, y) == nil
+		}))
+	}
+	return Equal(a, b)
+}
+
+// withoutKeys returns copies of entries without LogIgnoreKeys and ignoreKeys.
+func withoutKeys(entries []map[string]interface{}, ignoreKeys []string) []map[string]interface{} {
+	if entries == nil {
+		return nil
+	}
+	ignore := map[string]bool{}
+	for _, keys := range [][]string{LogIgnoreKeys, ignoreKeys} {
+		for _, k := range keys {
+			ignore[k] = true
+		}
+	}
+	out := make([]map[string]interface{}, len(entries))
+	for i, e := range entries {
+		out[i] = make(map[string]interface{}, len(e))
+		for k, v := range e {
+			if !ignore[k] {
+				out[i][k] = v
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,54 @@
+//go:build go1.21
+// +build go1.21
+
+package deep
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogEntries converts slog records to log entries for EqualLogs. Each entry
+// has keys "time", "level", and "msg", like slog.JSONHandler, plus one key per
+// attribute. Attributes in groups are named "group.key".
+func SlogEntries(records []slog.Record) []map[string]interface{} {
+	entries := make([]map[string]interface{}, len(records))
+	for i, r := range records {
+		e := map[string]interface{}{
+			"time":  r.Time,
+			"level": r.Level.String(),
+			"msg":   r.Message,
+		}
+		r.Attrs(func(attr slog.Attr) bool {
+			addSlogAttr(e, "", attr)
+			return true
+		})
+		entries[i] = e
+	}
+	return entries
+}
+
+func addSlogAttr(e map[string]interface{}, prefix string, attr slog.Attr) {
+	v := attr.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, a := range v.Group() {
+			addSlogAttr(e, prefix, a)
+		}
+		return
+	}
+	e[prefix+attr.Key] = v.Any()
+}
+
+// SlogLogger returns a Logger that logs errors to l at level Warn, with the
+// message "deep: " plus the error and attributes "path" and "err", like:
+//
+//	deep.ErrorLogger = deep.SlogLogger(slog.Default())
+func SlogLogger(l *slog.Logger) Logger {
+	return LoggerFunc(func(path string, err error) {
+		l.LogAttrs(context.Background(), slog.LevelWarn, "deep: "+err.Error(),
+			slog.String("path", path), slog.Any("err", err))
+	})
+}
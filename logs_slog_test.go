@@ -0,0 +1,48 @@
+//go:build go1.21
+// +build go1.21
+
+package deep_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+)
+
+func TestSlogEntries(t *testing.T) {
+	r1 := slog.NewRecord(time.Now(), slog.LevelInfo, "request", 0)
+	r1.AddAttrs(slog.Int("status", 200), slog.Group("req", slog.String("path", "/")))
+	r2 := slog.NewRecord(time.Now().Add(time.Second), slog.LevelInfo, "request", 0)
+	r2.AddAttrs(slog.Int("status", 404), slog.Group("req", slog.String("path", "/")))
+
+	diff := deep.EqualLogs(deep.SlogEntries([]slog.Record{r1}), deep.SlogEntries([]slog.Record{r2}), false)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "slice[0].map[status]: 200 != 404" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+}
+
+func TestSlogLogger(t *testing.T) {
+	defer func(l deep.Logger) { deep.ErrorLogger = l }(deep.ErrorLogger)
+	var buf bytes.Buffer
+	deep.ErrorLogger = deep.SlogLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	type T struct {
+		V interface{}
+	}
+	if diff := deep.Equal(T{1}, T{"1"}); len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %v", diff)
+	}
+	got := buf.String()
+	for _, want := range []string{"level=WARN", `msg="deep: variables are different reflect.Type"`, "path=V"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("log %q does not contain %q", got, want)
+		}
+	}
+}
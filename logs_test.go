@@ -0,0 +1,46 @@
+package deep_test
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestEqualLogs(t *testing.T) {
+	a := []map[string]interface{}{
+		{"time": "2024-01-01T00:00:00Z", "level": "INFO", "msg": "start"},
+		{"time": "2024-01-01T00:00:01Z", "level": "INFO", "msg": "done", "id": 1},
+	}
+	b := []map[string]interface{}{
+		{"time": "2024-06-01T00:00:00Z", "level": "INFO", "msg": "start"},
+		{"time": "2024-06-01T00:00:01Z", "level": "INFO", "msg": "done", "id": 2},
+	}
+	diff := deep.EqualLogs(a, b, false)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "slice[1].map[id]: 1 != 2" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+	diff = deep.EqualLogs(a, b, false, "id")
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+	if _, ok := a[0]["time"]; !ok {
+		t.Error("EqualLogs modified its input")
+	}
+
+	// Unordered
+	b[0], b[1] = b[1], b[0]
+	diff = deep.EqualLogs(a, b, true, "id")
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+	diff = deep.EqualLogs(a, b, true)
+	if len(diff) != 2 {
+		t.Fatalf("expected 2 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "slice[1]: map[id:1 level:INFO msg:done] != <no match>" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+}
@@ -0,0 +1,37 @@
+package deep
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// keyFormatters are the registered funcs to format map keys by type.
+var keyFormatters = map[reflect.Type]func(key reflect.Value) string{}
+
+// RegisterKeyFormatter registers func format to print map keys of type t in
+// diff paths, like "map[us-east/1]" instead of the default "map[{Region:us-east
+// ID:1}]". Keys are printed with %+v by default, so struct keys include their
+// field names. Keys are sorted by how they are printed, so format should
+// print different keys differently. A nil format unregisters t.
+//
+// RegisterKeyFormatter is not safe to call concurrently with Equal.
+func RegisterKeyFormatter(t reflect.Type, format func(key reflect.Value) string) {
+	if format == nil {
+		delete(keyFormatters, t)
+		return
+	}
+	keyFormatters[t] = format
+}
+
+// mapKeyName returns the path name of map key k, like "map[foo]".
+func mapKeyName(k reflect.Value) string {
+	if format, ok := keyFormatters[k.Type()]; ok {
+		return "map[" + format(k) + "]"
+	}
+	if k.Kind() == reflect.Interface && !k.IsNil() {
+		if format, ok := keyFormatters[k.Elem().Type()]; ok {
+			return "map[" + format(k.Elem()) + "]"
+		}
+	}
+	return fmt.Sprintf("map[%+v]", k)
+}
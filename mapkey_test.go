@@ -0,0 +1,46 @@
+package deep_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+type regionKey struct {
+	Region string
+	ID     int
+}
+
+func TestMapKeyFormat(t *testing.T) {
+	a := map[regionKey]int{{"us-east", 1}: 1, {"us-west", 2}: 2}
+	b := map[regionKey]int{{"us-east", 1}: 10, {"us-west", 2}: 2}
+	diff := deep.Equal(a, b)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %v", len(diff), diff)
+	}
+	if diff[0] != "map[{Region:us-east ID:1}]: 1 != 10" {
+		t.Errorf("got %q", diff[0])
+	}
+
+	keyType := reflect.TypeOf(regionKey{})
+	deep.RegisterKeyFormatter(keyType, func(k reflect.Value) string {
+		key := k.Interface().(regionKey)
+		return fmt.Sprintf("%s/%d", key.Region, key.ID)
+	})
+	defer deep.RegisterKeyFormatter(keyType, nil)
+
+	diff = deep.Equal(a, b)
+	if len(diff) != 1 || diff[0] != "map[us-east/1]: 1 != 10" {
+		t.Errorf("got %v", diff)
+	}
+
+	// Keys in interface{} use the formatter of their dynamic type
+	ai := map[interface{}]int{regionKey{"eu", 3}: 1}
+	bi := map[interface{}]int{regionKey{"eu", 3}: 2}
+	diff = deep.Equal(ai, bi)
+	if len(diff) != 1 || diff[0] != "map[eu/3]: 1 != 2" {
+		t.Errorf("got %v", diff)
+	}
+}
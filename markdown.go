@@ -0,0 +1,46 @@
+package deep
+
+import "strings"
+
+// Markdown returns the differences as a Markdown table with a row for each
+// difference, like:
+//
+//	| path | expected | actual |
+//	| --- | --- | --- |
+//	| `Address.City` | foo | bar |
+//
+// for posting in pull request comments or chat. Values are the A and B values
+// of the differences; a note, like "bytes differ at offset 2", is printed
+// after the path, and summary diffs, like "5 elements differ", are printed as
+// the expected value. Pipes are escaped and newlines are printed as <br>. If
+// there are no differences, Markdown returns an empty string.
+func (d Diff) Markdown() string {
+	if len(d.Differences) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("| path | expected | actual |\n")
+	b.WriteString("| --- | --- | --- |\n")
+	for _, diff := range d.Differences {
+		path := ""
+		if len(diff.Path) > 0 || PathRoot != "" {
+			path = "`" + strings.ReplaceAll(joinPath(diff.Path), "`", "'") + "`"
+		}
+		if diff.note != "" {
+			path += " (" + diff.note + ")"
+		}
+		expected, actual := diff.A, diff.B
+		if diff.text != "" {
+			expected = diff.values()
+		}
+		b.WriteString("| " + markdownCell(path) + " | " + markdownCell(expected) + " | " + markdownCell(actual) + " |\n")
+	}
+	return b.String()
+}
+
+// markdownCell returns s escaped for a Markdown table cell.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	s = strings.ReplaceAll(s, "\r\n", "<br>")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
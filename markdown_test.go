@@ -0,0 +1,29 @@
+package deep_test
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestMarkdown(t *testing.T) {
+	type T struct {
+		Name string
+		Expr string
+		Tags []string
+	}
+	a := T{Name: "foo", Expr: "a|b", Tags: []string{"x"}}
+	b := T{Name: "bar", Expr: "line1\nline2"}
+	want := "| path | expected | actual |\n" +
+		"| --- | --- | --- |\n" +
+		"| `Name` | foo | bar |\n" +
+		"| `Expr` | a\\|b | line1<br>line2 |\n" +
+		"| `Tags` | [x] | <nil slice> |\n"
+	if got := deep.Compare(a, b).Markdown(); got != want {
+		t.Errorf("got:\n%s\nexpected:\n%s", got, want)
+	}
+
+	if got := deep.Compare(a, a).Markdown(); got != "" {
+		t.Errorf("got %q, expected empty string", got)
+	}
+}
@@ -0,0 +1,74 @@
+package deep
+
+import (
+	"math"
+	"reflect"
+	"time"
+)
+
+// Matcher returns true if values a and b are equal; see WithMatcher.
+type Matcher func(a, b interface{}) bool
+
+// pathMatcher is the flag returned by WithMatcher.
+type pathMatcher struct {
+	pattern string
+	match   Matcher
+}
+
+// WithMatcher returns a flag for Equal that compares values at paths matching
+// pattern with m instead of comparing them normally. A pattern is a diff path
+// where "*" matches any part of one path segment, like "CreatedAt" or
+// "Items.slice[*].Score"; see Redact. Pointers and interfaces are dereferenced
+// before calling m, and nil values are compared normally. If m returns false,
+// the values are reported as a diff. If more than one pattern matches, the
+// first is used.
+func WithMatcher(pattern string, m Matcher) interface{} {
+	return pathMatcher{pattern: pattern, match: m}
+}
+
+// Within returns a Matcher for numbers, of any kind, that are equal if they
+// differ by no more than tolerance. Values that are not numbers are equal
+// only if they are deeply equal.
+func Within(tolerance float64) Matcher {
+	return func(a, b interface{}) bool {
+		x, y := reflect.ValueOf(a), reflect.ValueOf(b)
+		if !isNumber(x) || !isNumber(y) {
+			return Equal(a, b) == nil
+		}
+		return math.Abs(toFloat(x)-toFloat(y)) <= tolerance
+	}
+}
+
+// TimeWithin returns a Matcher for time.Time values that are equal if they
+// differ by no more than d. Values that are not time.Time are equal only if
+// they are deeply equal.
+func TimeWithin(d time.Duration) Matcher {
+	return func(a, b interface{}) bool {
+		t1, ok1 := a.(time.Time)
+		t2, ok2 := b.(time.Time)
+		if !ok1 || !ok2 {
+			return Equal(a, b) == nil
+		}
+		diff := t1.Sub(t2)
+		return diff <= d && diff >= -d
+	}
+}
+
+// equalsMatcher compares a and b with the Matcher for the current path, if
+// any, and returns true if they were compared.
+func (c *cmp) equalsMatcher(a, b reflect.Value) bool {
+	if a.Kind() == reflect.Ptr || a.Kind() == reflect.Interface ||
+		b.Kind() == reflect.Ptr || b.Kind() == reflect.Interface ||
+		!a.CanInterface() || !b.CanInterface() {
+		return false
+	}
+	for _, m := range c.pathMatchers {
+		if matchPath(m.pattern, c.buff) {
+			if !m.match(a.Interface(), b.Interface()) {
+				c.saveDiff(a, b)
+			}
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package deep_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+)
+
+func TestWithMatcher(t *testing.T) {
+	type item struct {
+		Name  string
+		Score float64
+	}
+	type T struct {
+		Items     []item
+		CreatedAt time.Time
+		Updated   *time.Time
+		Count     int
+	}
+	now := time.Now()
+	later := now.Add(500 * time.Millisecond)
+	a := T{
+		Items:     []item{{"x", 1.0}, {"y", 2.0}},
+		CreatedAt: now,
+		Updated:   &now,
+		Count:     1,
+	}
+	b := T{
+		Items:     []item{{"x", 1.4}, {"y", 2.0}},
+		CreatedAt: later,
+		Updated:   &later,
+		Count:     1,
+	}
+	flags := []interface{}{
+		deep.WithMatcher("Items.slice[*].Score", deep.Within(0.5)),
+		deep.WithMatcher("CreatedAt", deep.TimeWithin(time.Second)),
+		deep.WithMatcher("Updated", deep.TimeWithin(time.Second)),
+	}
+	if diff := deep.Equal(a, b, flags...); diff != nil {
+		t.Error(diff)
+	}
+
+	// Out of tolerance
+	b.Items[1].Score = 3.0
+	b.Count = 2
+	diff := deep.Equal(a, b, flags...)
+	want := []string{
+		"Items.slice[1].Score: 2 != 3",
+		"Count: 1 != 2",
+	}
+	if len(diff) != len(want) {
+		t.Fatalf("got %d diffs, expected %d: %v", len(diff), len(want), diff)
+	}
+	for i := range want {
+		if diff[i] != want[i] {
+			t.Errorf("got %q, expected %q", diff[i], want[i])
+		}
+	}
+
+	// Without matchers, values are compared strictly
+	if diff := deep.Equal(a, b); len(diff) != 5 {
+		t.Errorf("got %d diffs, expected 5: %v", len(diff), diff)
+	}
+}
+
+func TestWithin(t *testing.T) {
+	within := deep.Within(0.5)
+	if !within(1, 1.5) {
+		t.Error("1 and 1.5 not within 0.5")
+	}
+	if within(int8(1), uint(2)) {
+		t.Error("1 and 2 within 0.5")
+	}
+	if !within("a", "a") || within("a", "b") {
+		t.Error("non-numbers not compared deeply")
+	}
+}
@@ -0,0 +1,177 @@
+package deep
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EqualMetrics compares metrics in the Prometheus text exposition format, like
+// the body scraped from a /metrics endpoint, and returns a list of differences,
+// or nil if there are none. Samples are compared by metric name and labels,
+// regardless of label order; timestamps are ignored. Sample values are equal if
+// they differ by no more than tolerance, and NaN equals NaN. The TYPE of each
+// metric family is also compared. Differences are reported by sample, like
+// `http_requests_total{code="200",method="GET"}: 1 != 2`. An error is returned
+// if either input cannot be parsed.
+func EqualMetrics(a, b io.Reader, tolerance float64) ([]string, error) {
+	aSamples, aTypes, err := parseMetrics(a)
+	if err != nil {
+		return nil, err
+	}
+	bSamples, bTypes, err := parseMetrics(b)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := []string{}
+	save := func(name string, aVal, bVal interface{}) bool {
+		diff = append(diff, fmt.Sprintf("%s: %v != %v", name, aVal, bVal))
+		return len(diff) < maxDiff()
+	}
+	for _, name := range unionKeys(aTypes, bTypes) {
+		aType, bType := aTypes[name], bTypes[name]
+		if aType != bType && !save(name+" TYPE", orNone(aType), orNone(bType)) {
+			return diff, nil
+		}
+	}
+	for _, name := range unionKeys(aSamples, bSamples) {
+		aVal, aOK := aSamples[name]
+		bVal, bOK := bSamples[name]
+		switch {
+		case !bOK:
+			if !save(name, aVal, "<no sample>") {
+				return diff, nil
+			}
+		case !aOK:
+			if !save(name, "<no sample>", bVal) {
+				return diff, nil
+			}
+		case math.IsNaN(aVal) && math.IsNaN(bVal):
+		case aVal == bVal: // +Inf == +Inf
+		case math.Abs(aVal-bVal) > tolerance || math.IsNaN(aVal) || math.IsNaN(bVal):
+			if !save(name, aVal, bVal) {
+				return diff, nil
+			}
+		}
+	}
+	if len(diff) > 0 {
+		return diff, nil
+	}
+	return nil, nil
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
+
+// unionKeys returns the sorted keys of a and b.
+func unionKeys(a, b interface{}) []string {
+	keys := []string{}
+	seen := map[string]bool{}
+	for _, m := range []interface{}{a, b} {
+		switch m := m.(type) {
+		case map[string]string:
+			for k := range m {
+				if !seen[k] {
+					seen[k] = true
+					keys = append(keys, k)
+				}
+			}
+		case map[string]float64:
+			for k := range m {
+				if !seen[k] {
+					seen[k] = true
+					keys = append(keys, k)
+				}
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// parseMetrics returns the samples, keyed by name and sorted labels, and the
+// types, keyed by metric family name, in Prometheus text format r.
+func parseMetrics(r io.Reader) (map[string]float64, map[string]string, error) {
+	samples := map[string]float64{}
+	types := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			if f := strings.Fields(line); len(f) == 4 && f[1] == "TYPE" {
+				types[f[2]] = f[3]
+			}
+			continue
+		}
+		name, value, err := parseSample(line)
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %s", lineNo, err)
+		}
+		samples[name] = value
+	}
+	return samples, types, scanner.Err()
+}
+
+// parseSample parses a sample line like `name{b="2",a="1"} 3.5 1700000000`
+// and returns `name{a="1",b="2"}` and 3.5.
+func parseSample(line string) (string, float64, error) {
+	i := strings.IndexAny(line, "{ \t")
+	if i <= 0 {
+		return "", 0, fmt.Errorf("invalid sample: %s", line)
+	}
+	name, rest := line[:i], line[i:]
+	labels := []string{}
+	if rest[0] == '{' {
+		rest = rest[1:]
+		for {
+			rest = strings.TrimLeft(rest, " \t,")
+			if strings.HasPrefix(rest, "}") {
+				rest = rest[1:]
+				break
+			}
+			eq := strings.Index(rest, "=\"")
+			if eq <= 0 {
+				return "", 0, fmt.Errorf("invalid labels: %s", line)
+			}
+			// Find the closing quote, skipping escaped chars like \"
+			j := eq + 2
+			for j < len(rest) && rest[j] != '"' {
+				if rest[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(rest) {
+				return "", 0, fmt.Errorf("invalid label value: %s", line)
+			}
+			labels = append(labels, strings.TrimSpace(rest[:eq])+rest[eq:j+1])
+			rest = rest[j+1:]
+		}
+	}
+	f := strings.Fields(rest) // value [timestamp]
+	if len(f) == 0 {
+		return "", 0, fmt.Errorf("no value: %s", line)
+	}
+	value, err := strconv.ParseFloat(f[0], 64)
+	if err != nil {
+		return "", 0, err
+	}
+	if len(labels) > 0 {
+		sort.Strings(labels)
+		name += "{" + strings.Join(labels, ",") + "}"
+	}
+	return name, value, nil
+}
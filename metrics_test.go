@@ -0,0 +1,72 @@
+package deep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestEqualMetrics(t *testing.T) {
+	a := `# HELP http_requests_total Total requests.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",code="200"} 10 1700000000000
+http_requests_total{method="POST",code="500"} 1
+# TYPE latency_seconds gauge
+latency_seconds 0.25
+up NaN
+`
+	b := `# TYPE http_requests_total counter
+http_requests_total{code="200", method="GET"} 10 1800000000000
+http_requests_total{code="500",method="POST"} 1
+# TYPE latency_seconds gauge
+latency_seconds 0.2501
+up NaN
+`
+	diff, err := deep.EqualMetrics(strings.NewReader(a), strings.NewReader(b), 0.001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+
+	diff, err = deep.EqualMetrics(strings.NewReader(a), strings.NewReader(b), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "latency_seconds: 0.25 != 0.2501" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+
+	b = `# TYPE http_requests_total gauge
+http_requests_total{code="200",method="GET"} 11
+latency_seconds 0.25
+up NaN
+`
+	diff, err = deep.EqualMetrics(strings.NewReader(a), strings.NewReader(b), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{
+		"http_requests_total TYPE: counter != gauge",
+		"latency_seconds TYPE: gauge != <none>",
+		`http_requests_total{code="200",method="GET"}: 10 != 11`,
+		`http_requests_total{code="500",method="POST"}: 1 != <no sample>`,
+	}
+	if len(diff) != len(expect) {
+		t.Fatalf("expected %d diff, got %d: %s", len(expect), len(diff), diff)
+	}
+	for i := range expect {
+		if diff[i] != expect[i] {
+			t.Errorf("got '%s', expected '%s'", diff[i], expect[i])
+		}
+	}
+
+	if _, err := deep.EqualMetrics(strings.NewReader(`foo{a="1} 1`), strings.NewReader(""), 0); err == nil {
+		t.Error("expected parse error")
+	}
+}
@@ -0,0 +1,48 @@
+//go:build go1.18
+// +build go1.18
+
+package deep_test
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestNetip(t *testing.T) {
+	type route struct {
+		Dst     netip.Prefix
+		Gateway netip.Addr
+		Listen  netip.AddrPort
+	}
+	a := route{
+		Dst:     netip.MustParsePrefix("10.0.0.0/8"),
+		Gateway: netip.MustParseAddr("10.0.0.1"),
+		Listen:  netip.MustParseAddrPort("[::1]:80"),
+	}
+	b := a
+	if diff := deep.Equal(a, b); diff != nil {
+		t.Error(diff)
+	}
+
+	b.Dst = netip.MustParsePrefix("10.0.0.0/16")
+	b.Gateway = netip.MustParseAddr("::ffff:10.0.0.1") // IPv4-mapped is different
+	b.Listen = netip.AddrPort{}
+	diff := deep.Equal(a, b)
+	expect := []string{
+		"Dst: 10.0.0.0/8 != 10.0.0.0/16",
+		"Gateway: 10.0.0.1 != ::ffff:10.0.0.1",
+		"Listen: [::1]:80 != invalid AddrPort",
+	}
+	if d := deep.Equal(diff, expect); d != nil {
+		t.Error(d)
+	}
+
+	// Canonical strings, not internals, with CompareUnexportedFields
+	deep.CompareUnexportedFields = true
+	defer func() { deep.CompareUnexportedFields = false }()
+	if diff := deep.Equal(netip.MustParseAddr("fe80::1%eth0"), netip.MustParseAddr("fe80::1%eth1")); len(diff) != 1 {
+		t.Errorf("expected 1 diff, got %d: %v", len(diff), diff)
+	}
+}
@@ -0,0 +1,92 @@
+package deep
+
+import "sync"
+
+// parallel returns true if n elements are compared in parallel; see
+// Parallelism.
+func (c *cmp) parallel(n int) bool {
+	return Parallelism > 1 && !c.serial && n >= ParallelMinLen && n > 1 &&
+		SummarizeRepeatedDiffs == 0 && SampleElementDiffs == 0 && !LockValues
+}
+
+// fork returns a copy of c, without diffs, to compare elements in another
+// goroutine.
+func (c *cmp) fork() *cmp {
+	f := *c
+	f.diff, f.details, f.warnings = nil, nil, nil
+	f.pathDiffs, f.stopped, f.forked = nil, nil, true
+	f.buff = append([]string(nil), c.buff...)
+	f.locked = nil
+	f.visiting = make(map[visit]bool, len(c.visiting))
+	for v := range c.visiting {
+		f.visiting[v] = true
+	}
+	return &f
+}
+
+// compareParallel calls elem(f, i) for every element i in [0, n), where f is
+// a fork of c, in Parallelism goroutines that each compare a contiguous chunk
+// of elements. Then it saves the diffs in order of i, up to MaxDiff like a
+// serial loop, so the diffs are the same as if the elements were compared
+// serially.
+func (c *cmp) compareParallel(n int, elem func(f *cmp, i int)) {
+	workers := Parallelism
+	if workers > n {
+		workers = n
+	}
+	size := (n + workers - 1) / workers
+	budget := c.maxDiff - len(c.diff)
+	forks := make([]*cmp, workers)
+	ends := make([][]int, workers) // end of diffs of each differing element
+	var wg sync.WaitGroup
+	for k := range forks {
+		start, end := k*size, (k+1)*size
+		if end > n {
+			end = n
+		}
+		f := c.fork()
+		forks[k] = f
+		wg.Add(1)
+		go func(k int) {
+			defer wg.Done()
+			for i := start; i < end && len(f.diff) < budget; i++ {
+				prev := len(f.diff)
+				elem(f, i)
+				if len(f.diff) > prev {
+					ends[k] = append(ends[k], len(f.diff))
+				}
+			}
+		}(k)
+	}
+	wg.Wait()
+	for _, f := range forks {
+		if f.ctxErr != nil {
+			c.ctxErr = f.ctxErr
+		}
+		c.warnings = append(c.warnings, f.warnings...)
+	}
+
+	for k, f := range forks {
+		prev := 0
+		for _, end := range ends[k] {
+			if MaxDiffPerPath > 0 && !c.forked {
+				for i := prev; i < end; i++ {
+					if len(f.details[i].Path) == 0 || c.countPath(f.details[i].Path[0]) {
+						c.diff = append(c.diff, f.diff[i])
+						c.details = append(c.details, f.details[i])
+					}
+				}
+			} else {
+				c.diff = append(c.diff, f.diff[prev:end]...)
+				c.details = append(c.details, f.details[prev:end]...)
+			}
+			prev = end
+			if len(c.buff) == 0 {
+				c.saveStopped() // each element is a top-level path
+			}
+			if c.full() {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package deep_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestParallelism(t *testing.T) {
+	defer func(p, n, max int) {
+		deep.Parallelism, deep.ParallelMinLen, deep.MaxDiff = p, n, max
+	}(deep.Parallelism, deep.ParallelMinLen, deep.MaxDiff)
+	deep.ParallelMinLen = 10
+	deep.MaxDiff = 1000
+
+	type row struct {
+		ID   int
+		Name string
+	}
+	aSlice := make([]row, 1000)
+	bSlice := make([]row, 1001)
+	aMap := map[string]row{}
+	bMap := map[string]row{}
+	var aArray, bArray [100]int
+	for i := range aSlice {
+		aSlice[i] = row{i, "a"}
+		bSlice[i] = row{i, "a"}
+		aMap[fmt.Sprint(i)] = row{i, "a"}
+		bMap[fmt.Sprint(i)] = row{i, "a"}
+		if i%97 == 0 {
+			bSlice[i] = row{i + 1, "b"} // 2 diffs
+			bMap[fmt.Sprint(i)] = row{i + 1, "b"}
+		}
+		if i < 100 && i%7 == 0 {
+			bArray[i] = i
+		}
+	}
+	bSlice[1000] = row{1000, "new"}
+	bMap["new"] = row{}
+	delete(bMap, "5")
+
+	for _, maxDiff := range []int{1000, 5} {
+		deep.MaxDiff = maxDiff
+		for _, v := range []struct {
+			name string
+			a, b interface{}
+		}{
+			{"slice", aSlice, bSlice},
+			{"map", aMap, bMap},
+			{"array", aArray, bArray},
+		} {
+			deep.Parallelism = 0
+			serial := deep.Equal(v.a, v.b)
+			if len(serial) == 0 {
+				t.Fatalf("%s: no diffs", v.name)
+			}
+			deep.Parallelism = 4
+			if diff := deep.Equal(deep.Equal(v.a, v.b), serial); diff != nil {
+				t.Errorf("%s, MaxDiff=%d: parallel diffs differ from serial: %v", v.name, maxDiff, diff)
+			}
+		}
+	}
+
+	// Equal
+	deep.Parallelism = 4
+	if diff := deep.Equal(aSlice, aSlice[:]); diff != nil {
+		t.Error(diff)
+	}
+	if diff := deep.Equal(aMap, map[string]row(aMap)); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func BenchmarkParallelism(b *testing.B) {
+	defer func(p int) { deep.Parallelism = p }(deep.Parallelism)
+	x := make([]map[string]int, 100000)
+	y := make([]map[string]int, 100000)
+	for i := range x {
+		x[i] = map[string]int{"a": i, "b": i}
+		y[i] = map[string]int{"a": i, "b": i}
+	}
+	for _, p := range []int{0, 4} {
+		deep.Parallelism = p
+		b.Run(fmt.Sprintf("Parallelism=%d", p), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				deep.Equal(x, y)
+			}
+		})
+	}
+}
+
+func TestParallelMaxDiffPerPath(t *testing.T) {
+	defer func(p, n, m int) { deep.Parallelism, deep.ParallelMinLen, deep.MaxDiffPerPath = p, n, m }(deep.Parallelism, deep.ParallelMinLen, deep.MaxDiffPerPath)
+	deep.Parallelism = 4
+	deep.ParallelMinLen = 1
+	deep.MaxDiffPerPath = 2
+
+	type T struct {
+		Items []int
+		Name  string
+	}
+	a := T{Items: make([]int, 100), Name: "a"}
+	b := T{Items: make([]int, 100), Name: "b"}
+	for i := range b.Items {
+		b.Items[i] = i + 1
+	}
+	diff := deep.Equal(a, b)
+	want := []string{
+		"Items.slice[0]: 0 != 1",
+		"Items.slice[1]: 0 != 2",
+		"Items: <stopped after MaxDiffPerPath=2> != <stopped after MaxDiffPerPath=2>",
+		"Name: a != b",
+	}
+	if len(diff) != len(want) {
+		t.Fatalf("got %d diffs, expected %d: %v", len(diff), len(want), diff)
+	}
+	for i := range want {
+		if diff[i] != want[i] {
+			t.Errorf("got %q, expected %q", diff[i], want[i])
+		}
+	}
+}
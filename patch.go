@@ -0,0 +1,113 @@
+package deep
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// jsonPatchOp is one RFC 6902 JSON Patch operation.
+type jsonPatchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// pathElement matches map keys and slice and array indexes in paths, like
+// "map[foo]" or "slice[0]".
+var pathElement = regexp.MustCompile(`^(?:map|slice|array)\[(.*)\]$`)
+
+// JSONPatch returns an RFC 6902 JSON Patch that transforms A into B, for
+// values like JSON documents decoded into interface{} or structs that are
+// encoded to JSON with their field names (or with JSONTagNames true). For
+// example, diff "Spec.map[replicas]: 1 != 2" is exported as
+// {"op":"replace","path":"/Spec/replicas","value":2}. Values that B does not
+// have, like map keys and slice elements, are removed, and values that A
+// does not have are added. An error is returned if a difference cannot be
+// exported, like a type mismatch, a redacted value, or a summary diff.
+func (d Diff) JSONPatch() ([]byte, error) {
+	ops := []jsonPatchOp{}
+	var removes []jsonPatchOp // slice elements, removed last to first
+	for _, diff := range d.Differences {
+		if diff.text != "" {
+			return nil, fmt.Errorf("%s: cannot export summary diff", diff)
+		}
+		path := patchPointer(diff.Path)
+		switch {
+		case isMarker(diff.b, "<does not have key>", "<no value>"):
+			op := jsonPatchOp{Op: "remove", Path: path}
+			if len(diff.Path) > 0 && strings.HasPrefix(diff.Path[len(diff.Path)-1], "slice[") {
+				removes = append([]jsonPatchOp{op}, removes...)
+			} else {
+				ops = append(ops, op)
+			}
+		case isMarker(diff.a, "<does not have key>", "<no value>"):
+			v, err := jsonValue(diff, diff.b)
+			if err != nil {
+				return nil, err
+			}
+			ops = append(ops, jsonPatchOp{Op: "add", Path: path, Value: v})
+		default:
+			v, err := jsonValue(diff, diff.b)
+			if err != nil {
+				return nil, err
+			}
+			ops = append(ops, jsonPatchOp{Op: "replace", Path: path, Value: v})
+		}
+	}
+	return json.Marshal(append(ops, removes...))
+}
+
+// patchPointer returns path as an RFC 6901 JSON Pointer, like "/Spec/replicas"
+// for path "Spec.map[replicas]".
+func patchPointer(path []string) string {
+	var p strings.Builder
+	for _, s := range path {
+		if m := pathElement.FindStringSubmatch(s); m != nil {
+			s = m[1]
+		}
+		s = strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+		p.WriteString("/" + s)
+	}
+	return p.String()
+}
+
+// isMarker returns true if v is one of markers.
+func isMarker(v interface{}, markers ...string) bool {
+	m, ok := v.(marker)
+	if !ok {
+		return false
+	}
+	for _, s := range markers {
+		if string(m) == s {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonValue returns value v of diff to encode as JSON.
+func jsonValue(diff Difference, v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case marker:
+		if isMarker(v, "<nil pointer>", "<nil map>", "<nil slice>") {
+			return json.RawMessage("null"), nil
+		}
+		return nil, fmt.Errorf("%s: cannot export %s", diff, v)
+	case reflect.Type:
+		return nil, fmt.Errorf("%s: cannot export type mismatch", diff)
+	case reflect.Value:
+		if !v.IsValid() {
+			return json.RawMessage("null"), nil
+		}
+		if !v.CanInterface() {
+			return nil, fmt.Errorf("%s: cannot export unexported value", diff)
+		}
+		return v.Interface(), nil
+	case nil:
+		return json.RawMessage("null"), nil
+	}
+	return v, nil
+}
@@ -0,0 +1,52 @@
+package deep_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestJSONPatch(t *testing.T) {
+	var a, b interface{}
+	json.Unmarshal([]byte(`{"name":"a","spec":{"replicas":1,"a/b":true},"tags":["x","y","z"],"old":1}`), &a)
+	json.Unmarshal([]byte(`{"name":"b","spec":{"replicas":2,"a/b":true},"tags":["x"],"new":{"k":"v"}}`), &b)
+
+	deep.MaxDiff = 20
+	defer func() { deep.MaxDiff = 10 }()
+
+	patch, err := deep.Compare(a, b).JSONPatch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []map[string]interface{}
+	if err := json.Unmarshal(patch, &got); err != nil {
+		t.Fatal(err)
+	}
+	var expect []map[string]interface{}
+	json.Unmarshal([]byte(`[
+		{"op":"replace","path":"/name","value":"b"},
+		{"op":"remove","path":"/old"},
+		{"op":"replace","path":"/spec/replicas","value":2},
+		{"op":"add","path":"/new","value":{"k":"v"}},
+		{"op":"remove","path":"/tags/2"},
+		{"op":"remove","path":"/tags/1"}
+	]`), &expect)
+	// Map iteration order is random, so compare ops in any order
+	matchOp := deep.ElementMatcher(func(a, b map[string]interface{}) bool {
+		return a["path"] == b["path"]
+	})
+	if diff := deep.Equal(got, expect, matchOp); diff != nil {
+		t.Errorf("%s\n%s", patch, diff)
+	}
+
+	// Slice removes are last to first
+	if got[len(got)-1]["path"] != "/tags/1" {
+		t.Errorf("wrong order: %s", patch)
+	}
+
+	// Type mismatches cannot be exported
+	if _, err := deep.Compare(1, "1").JSONPatch(); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,40 @@
+package deep_test
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+type benchUser struct {
+	Name  string
+	Age   int
+	Tags  []string
+	Attrs map[string]string
+}
+
+func BenchmarkEqualSmall(b *testing.B) {
+	x, y := 1, 1
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		deep.Equal(x, y)
+	}
+}
+
+func BenchmarkEqualStruct(b *testing.B) {
+	x := benchUser{"alice", 30, []string{"a", "b"}, map[string]string{"k": "v"}}
+	y := benchUser{"alice", 30, []string{"a", "b"}, map[string]string{"k": "v"}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		deep.Equal(x, y)
+	}
+}
+
+func BenchmarkEqualStructDiff(b *testing.B) {
+	x := benchUser{"alice", 30, []string{"a", "b"}, map[string]string{"k": "v"}}
+	y := benchUser{"bob", 30, []string{"a", "c"}, map[string]string{"k": "v"}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		deep.Equal(x, y)
+	}
+}
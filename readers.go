@@ -0,0 +1,81 @@
+package deep
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// readerChunk is the number of bytes read from each reader at a time by
+// EqualReaders.
+const readerChunk = 32 * 1024
+
+// EqualReaders compares the bytes read from a and b and returns a list of
+// differences, or nil if there are none, and the first read error other than
+// io.EOF. The readers are read in chunks, so large streams are compared
+// without reading them into memory. Each byte that differs is reported by
+// its offset, like "offset[1042]: 97 != 98", up to MaxDiff differences, after
+// which reading stops. If one reader has more bytes, the lengths are reported
+// like "len: 10 != 12", which requires reading the rest of the longer reader.
+func EqualReaders(a, b io.Reader) ([]string, error) {
+	c := newCmp(nil)
+	defer c.release()
+	aBuf, bBuf := make([]byte, readerChunk), make([]byte, readerChunk)
+	var offset int64
+	for !c.full() {
+		an, err := readChunk(a, aBuf)
+		if err != nil {
+			return c.result(), err
+		}
+		bn, err := readChunk(b, bBuf)
+		if err != nil {
+			return c.result(), err
+		}
+		n := an
+		if bn < n {
+			n = bn
+		}
+		for i := 0; i < n && !c.full(); i++ {
+			if aBuf[i] != bBuf[i] {
+				c.push(fmt.Sprintf("offset[%d]", offset+int64(i)))
+				c.saveDiff(aBuf[i], bBuf[i])
+				c.pop()
+			}
+		}
+		offset += int64(n)
+		if an != bn {
+			if c.full() {
+				break
+			}
+			aLen, bLen := offset+int64(an-n), offset+int64(bn-n)
+			rest, err := io.Copy(ioutil.Discard, a)
+			aLen += rest
+			if err != nil {
+				return c.result(), err
+			}
+			rest, err = io.Copy(ioutil.Discard, b)
+			bLen += rest
+			if err != nil {
+				return c.result(), err
+			}
+			c.push("len")
+			c.saveDiff(aLen, bLen)
+			c.pop()
+			break
+		}
+		if an < len(aBuf) {
+			break // both at EOF
+		}
+	}
+	return c.result(), nil
+}
+
+// readChunk reads up to len(p) bytes from r into p, returning fewer only at
+// EOF.
+func readChunk(r io.Reader, p []byte) (int, error) {
+	n, err := io.ReadFull(r, p)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	return n, err
+}
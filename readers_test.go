@@ -0,0 +1,67 @@
+package deep_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestEqualReaders(t *testing.T) {
+	a := bytes.Repeat([]byte("abcdefgh"), 10000) // larger than one chunk
+	b := append([]byte(nil), a...)
+	diff, err := deep.EqualReaders(bytes.NewReader(a), bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != nil {
+		t.Error(diff)
+	}
+
+	b[1] = 'X'
+	b[40000] = 'Y'
+	b = append(b, "extra"...)
+	diff, err = deep.EqualReaders(bytes.NewReader(a), bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"offset[1]: 98 != 88",
+		"offset[40000]: 97 != 89",
+		"len: 80000 != 80005",
+	}
+	if len(diff) != len(want) {
+		t.Fatalf("got %d diffs, expected %d: %v", len(diff), len(want), diff)
+	}
+	for i := range want {
+		if diff[i] != want[i] {
+			t.Errorf("got %q, expected %q", diff[i], want[i])
+		}
+	}
+
+	// Stops at MaxDiff
+	diff, err = deep.EqualReaders(strings.NewReader(strings.Repeat("a", 100)), strings.NewReader(strings.Repeat("b", 100)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != deep.MaxDiff {
+		t.Errorf("got %d diffs, expected %d", len(diff), deep.MaxDiff)
+	}
+
+	readErr := errors.New("read failed")
+	_, err = deep.EqualReaders(strings.NewReader("a"), io.MultiReader(strings.NewReader("a"), errReader{readErr}))
+	if !errors.Is(err, readErr) {
+		t.Errorf("got error %v, expected %v", err, readErr)
+	}
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,98 @@
+package deep
+
+import "reflect"
+
+// ComparedPath is the path of a value compared by EqualityReport.
+type ComparedPath struct {
+	// Path is like a diff path split by ".", like []string{"Tags",
+	// "slice[0]"}, or empty for the top-level values.
+	Path []string
+
+	// Equal is true if the values at Path are equal.
+	Equal bool
+}
+
+// Report is the result of EqualityReport.
+type Report struct {
+	// Paths are the paths of the leaf values compared, like struct fields
+	// with scalar values, in the order compared, whether equal or not.
+	// Values that have no children, like empty slices, are leaves, too.
+	Paths []ComparedPath
+
+	// Diff is the differences, like Compare returns.
+	Diff Diff
+}
+
+// EqualityReport compares a and b like Compare and returns a report of every
+// leaf value compared, not only the values that differ, so tools can show
+// which values were verified or compute how similar a and b are; see
+// Report.Similarity. Like Compare, comparing stops when MaxDiff differences
+// are found, so set MaxDiff higher to report every value. Values are compared
+// serially even if Parallelism is set.
+func EqualityReport(a, b interface{}, flags ...interface{}) Report {
+	c := newCmp(flags)
+	defer c.release()
+	return c.report(a, b)
+}
+
+// Similarity returns the fraction of leaf values of a and b that are equal,
+// from 0 (none) to 1 (all), like EqualityReport(a, b, flags...).Similarity()
+// but every value is compared, regardless of MaxDiff. It shows how different
+// a and b are, for example, to find duplicate records.
+func Similarity(a, b interface{}, flags ...interface{}) float64 {
+	c := newCmp(flags)
+	defer c.release()
+	c.maxDiff = maxInt
+	return c.report(a, b).Similarity()
+}
+
+// report compares a and b and returns the report; see EqualityReport.
+func (c *cmp) report(a, b interface{}) Report {
+	c.serial = true
+	var r Report
+	var last []string // path of the last value compared
+	saved := 0        // number of details reported
+	next := c.onCompare
+	c.onCompare = func(path []string, aVal, bVal reflect.Value, equal bool) {
+		if next != nil {
+			next(path, aVal, bVal, equal)
+		}
+		// Diffs saved without comparing values, like a missing map key,
+		// are leaves that differ
+		for _, d := range c.details[saved:] {
+			if len(d.Path) > len(path) && hasPathPrefix(d.Path, path) {
+				r.Paths = append(r.Paths, ComparedPath{Path: d.Path})
+				last = d.Path
+			}
+		}
+		saved = len(c.details)
+		// Values are reported after their children, so a value is a leaf if
+		// the last value compared is not below it or the same value, like a
+		// pointer and the value it points to
+		if last == nil || !hasPathPrefix(last, path) {
+			r.Paths = append(r.Paths, ComparedPath{Path: path, Equal: equal})
+		}
+		last = path
+	}
+	c.compare(a, b)
+	for _, d := range c.details[saved:] { // top-level nil
+		r.Paths = append(r.Paths, ComparedPath{Path: d.Path})
+	}
+	r.Diff = Diff{Differences: c.details, warnings: c.warnings}
+	return r
+}
+
+// Similarity returns the fraction of leaf values that are equal, from 0 (none)
+// to 1 (all). It is 1 if no values were compared.
+func (r Report) Similarity() float64 {
+	if len(r.Paths) == 0 {
+		return 1
+	}
+	equal := 0
+	for _, p := range r.Paths {
+		if p.Equal {
+			equal++
+		}
+	}
+	return float64(equal) / float64(len(r.Paths))
+}
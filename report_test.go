@@ -0,0 +1,95 @@
+package deep_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestEqualityReport(t *testing.T) {
+	type user struct {
+		Name  string
+		Tags  []string
+		Attrs map[string]int
+		Next  *user
+	}
+	a := user{
+		Name:  "alice",
+		Tags:  []string{"x"},
+		Attrs: map[string]int{"a": 1, "b": 2},
+		Next:  &user{Name: "bob"},
+	}
+	b := user{
+		Name:  "alice",
+		Tags:  []string{"y", "z"},
+		Attrs: map[string]int{"a": 1, "c": 3},
+		Next:  &user{Name: "bob"},
+	}
+	r := deep.EqualityReport(a, b)
+	var paths []string
+	for _, p := range r.Paths {
+		paths = append(paths, fmt.Sprintf("%s %t", strings.Join(p.Path, "."), p.Equal))
+	}
+	expect := []string{
+		"Name true",
+		"Tags.slice[0] false",
+		"Tags.slice[1] false",
+		"Attrs.map[a] true",
+		"Attrs.map[b] false",
+		"Attrs.map[c] false",
+		"Next.Name true",
+		"Next.Tags true",
+		"Next.Attrs true",
+		"Next.Next true",
+	}
+	if d := deep.Equal(paths, expect); d != nil {
+		t.Error(d)
+	}
+	if r.Diff.Len() != 4 {
+		t.Errorf("got %d diffs, expected 4: %v", r.Diff.Len(), r.Diff.Strings())
+	}
+	if s := r.Similarity(); s != 0.6 {
+		t.Errorf("got similarity %f, expected 0.6", s)
+	}
+
+	// Top-level
+	r = deep.EqualityReport(nil, 1)
+	if len(r.Paths) != 1 || r.Paths[0].Equal || r.Similarity() != 0 {
+		t.Errorf("wrong report: %+v", r)
+	}
+	r = deep.EqualityReport(1, 1)
+	if len(r.Paths) != 1 || !r.Paths[0].Equal || r.Similarity() != 1 {
+		t.Errorf("wrong report: %+v", r)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	a := make([]int, 100)
+	b := make([]int, 100)
+	for i := 0; i < 25; i++ {
+		b[i] = 1 // more than MaxDiff
+	}
+	if s := deep.Similarity(a, b); s != 0.75 {
+		t.Errorf("got %f, expected 0.75", s)
+	}
+	if s := deep.Similarity(a, a); s != 1 {
+		t.Errorf("got %f, expected 1", s)
+	}
+	if s := deep.Similarity(1, "1"); s != 0 {
+		t.Errorf("got %f, expected 0", s)
+	}
+
+	// Options apply
+	type rec struct {
+		ID   int
+		Name string
+	}
+	if s := deep.Similarity(rec{1, "a"}, rec{2, "a"}, deep.IgnorePaths("ID")); s != 1 {
+		t.Errorf("got %f, expected 1", s)
+	}
+	if s := deep.Similarity(rec{1, "a"}, rec{2, "a"}); s != 0.5 {
+		t.Errorf("got %f, expected 0.5", s)
+	}
+}
@@ -0,0 +1,198 @@
+package deep
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// schemaDocKeywords are schema keywords that only document a schema, so
+// changing them is never breaking.
+var schemaDocKeywords = map[string]bool{
+	"description":  true,
+	"title":        true,
+	"summary":      true,
+	"example":      true,
+	"examples":     true,
+	"$comment":     true,
+	"deprecated":   true,
+	"externalDocs": true,
+}
+
+// schemaNameKeywords are schema keywords whose keys or elements are names or
+// values, not keywords, like the property "title" in "properties.title".
+var schemaNameKeywords = map[string]bool{
+	"properties":        true,
+	"patternProperties": true,
+	"required":          true,
+	"enum":              true,
+}
+
+// EqualSchema compares JSON Schema or OpenAPI documents a and b, where b is a
+// new version of a, and returns the differences classified as breaking or
+// non-breaking changes, or nil if there are none. The documents are compared
+// like EqualJSON with three differences:
+//
+//   - "required" and "enum" arrays are sets: order does not matter, and
+//     elements are reported like "map[required].map[name]: true != <does not have key>"
+//   - local references like {"$ref": "#/components/schemas/User"} are resolved,
+//     so a reference and an inlined definition are equal; then definitions
+//     ("definitions", "$defs", and "components.schemas") are not compared
+//   - changes are classified from the point of view of a client sending data
+//     that must validate against the schema
+//
+// Non-breaking changes are changes to documentation keywords like description
+// and example, new optional properties, removed required properties, and new
+// enum values. All other changes are breaking, including removed properties,
+// new required properties, removed enum values, and changed types. A property
+// named like a documentation keyword, like "title", is a property, not
+// documentation.
+//
+// All differences are returned, regardless of MaxDiff, so no change is left
+// unclassified. An error is returned if either document is not valid JSON.
+func EqualSchema(a, b []byte) (breaking, nonBreaking []string, err error) {
+	aDoc, err := decodeJSON(a)
+	if err != nil {
+		return nil, nil, err
+	}
+	bDoc, err := decodeJSON(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	aDoc = normalizeSchema(aDoc)
+	bDoc = normalizeSchema(bDoc)
+
+	c := newCmp(nil)
+	defer c.release()
+	c.maxDiff = maxInt
+	c.compareValues(aDoc, bDoc)
+	for i, d := range c.details {
+		if isBreakingSchemaChange(d) {
+			breaking = append(breaking, c.diff[i])
+		} else {
+			nonBreaking = append(nonBreaking, c.diff[i])
+		}
+	}
+	return breaking, nonBreaking, nil
+}
+
+// normalizeSchema resolves local references, removes definitions, and
+// replaces required and enum arrays with sets.
+func normalizeSchema(doc interface{}) interface{} {
+	doc = resolveRefs(doc, doc, map[string]bool{})
+	if m, ok := doc.(map[string]interface{}); ok {
+		delete(m, "definitions")
+		delete(m, "$defs")
+		if components, ok := m["components"].(map[string]interface{}); ok {
+			delete(components, "schemas")
+			if len(components) == 0 {
+				delete(m, "components")
+			}
+		}
+	}
+	return schemaSets(doc)
+}
+
+// resolveRefs returns a copy of v with local references resolved against
+// root. A reference is not resolved if it is circular (in seen).
+func resolveRefs(root, v interface{}, seen map[string]bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok && strings.HasPrefix(ref, "#") && !seen[ref] {
+			if target, ok := jsonPointer(root, ref[1:]); ok {
+				seen[ref] = true
+				resolved := resolveRefs(root, target, seen)
+				delete(seen, ref)
+				return resolved
+			}
+		}
+		out := make(map[string]interface{}, len(v))
+		for k := range v {
+			out[k] = resolveRefs(root, v[k], seen)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i := range v {
+			out[i] = resolveRefs(root, v[i], seen)
+		}
+		return out
+	}
+	return v
+}
+
+// jsonPointer returns the value at RFC 6901 JSON pointer p in doc.
+func jsonPointer(doc interface{}, p string) (interface{}, bool) {
+	if p == "" {
+		return doc, true
+	}
+	if !strings.HasPrefix(p, "/") {
+		return nil, false
+	}
+	for _, token := range strings.Split(p[1:], "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		m, ok := doc.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if doc, ok = m[token]; !ok {
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// schemaSets replaces "required" and "enum" arrays in v with sets:
+// map[string]bool keyed by the (JSON-encoded, if not string) elements.
+func schemaSets(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k := range v {
+			if arr, ok := v[k].([]interface{}); ok && (k == "required" || k == "enum") {
+				set := make(map[string]bool, len(arr))
+				for _, e := range arr {
+					if s, ok := e.(string); ok {
+						set[s] = true
+					} else {
+						// Not formatValue, which depends on FormatValue and
+						// MaxValueLength, so equal elements are the same key
+						p, _ := json.Marshal(e)
+						set[string(p)] = true
+					}
+				}
+				v[k] = set
+				continue
+			}
+			v[k] = schemaSets(v[k])
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = schemaSets(v[i])
+		}
+	}
+	return v
+}
+
+// isBreakingSchemaChange returns true if diff d is a breaking change.
+func isBreakingSchemaChange(d Difference) bool {
+	keys := make([]string, len(d.Path))
+	for i, p := range d.Path {
+		keys[i] = strings.TrimSuffix(strings.TrimPrefix(p, "map["), "]")
+	}
+	for i, k := range keys {
+		if schemaDocKeywords[k] && (i == 0 || !schemaNameKeywords[keys[i-1]]) {
+			return false
+		}
+	}
+	added := d.category == missingKey && d.a == marker("<does not have key>")
+	removed := d.category == missingKey && d.b == marker("<does not have key>")
+	n := len(keys)
+	switch {
+	case n >= 2 && keys[n-2] == "required":
+		return added // new required property
+	case n >= 2 && keys[n-2] == "enum":
+		return removed // removed enum value
+	case n >= 2 && keys[n-2] == "properties":
+		return removed // removed property
+	}
+	return true
+}
@@ -0,0 +1,164 @@
+package deep_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestEqualSchema(t *testing.T) {
+	a := []byte(`{
+		"components": {"schemas": {"Name": {"type": "string", "description": "Full name"}}},
+		"type": "object",
+		"required": ["id", "name"],
+		"properties": {
+			"id": {"type": "integer"},
+			"name": {"$ref": "#/components/schemas/Name"},
+			"color": {"enum": ["red", "green"]}
+		}
+	}`)
+	b := []byte(`{
+		"type": "object",
+		"required": ["name", "id"],
+		"properties": {
+			"id": {"type": "integer"},
+			"name": {"type": "string", "description": "Full name"},
+			"color": {"enum": ["green", "red"]}
+		}
+	}`)
+	breaking, nonBreaking, err := deep.EqualSchema(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(breaking) != 0 || len(nonBreaking) != 0 {
+		t.Fatalf("expected 0 diff, got %s and %s", breaking, nonBreaking)
+	}
+
+	b = []byte(`{
+		"type": "object",
+		"required": ["name", "email"],
+		"properties": {
+			"id": {"type": "string"},
+			"name": {"type": "string", "description": "Name"},
+			"email": {"type": "string"},
+			"color": {"enum": ["green", "blue"]}
+		}
+	}`)
+	breaking, nonBreaking, err = deep.EqualSchema(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectBreaking := map[string]bool{
+		"map[required].map[email]: <does not have key> != true":                      true,
+		"map[properties].map[id].map[type]: integer != string":                       true,
+		"map[properties].map[color].map[enum].map[red]: true != <does not have key>": true,
+	}
+	expectNonBreaking := map[string]bool{
+		"map[required].map[id]: true != <does not have key>":                          true,
+		"map[properties].map[name].map[description]: Full name != Name":               true,
+		"map[properties].map[email]: map[type:string] != <does not have key>":         false,
+		"map[properties].map[email]: <does not have key> != map[type:string]":         true,
+		"map[properties].map[color].map[enum].map[blue]: <does not have key> != true": true,
+	}
+	for _, d := range breaking {
+		if !expectBreaking[d] {
+			t.Errorf("unexpected breaking change: %s", d)
+		}
+	}
+	for _, d := range nonBreaking {
+		if !expectNonBreaking[d] {
+			t.Errorf("unexpected non-breaking change: %s", d)
+		}
+	}
+	if len(breaking) != 3 || len(nonBreaking) != 4 {
+		t.Errorf("got %d breaking and %d non-breaking changes: %s, %s", len(breaking), len(nonBreaking), breaking, nonBreaking)
+	}
+
+	// Circular references are not resolved
+	circular := []byte(`{"$defs": {"node": {"properties": {"next": {"$ref": "#/$defs/node"}}}}, "$ref": "#/$defs/node"}`)
+	if _, _, err := deep.EqualSchema(circular, circular); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEqualSchemaFormatting(t *testing.T) {
+	defer func(root string, n int, f func(reflect.Value) string) {
+		deep.PathRoot, deep.MaxValueLength, deep.FormatValue = root, n, f
+	}(deep.PathRoot, deep.MaxValueLength, deep.FormatValue)
+	deep.PathRoot = "schema"
+	deep.MaxValueLength = 4
+	deep.FormatValue = func(v reflect.Value) string { return "value" }
+
+	a := []byte(`{"properties": {"id": {"type": "integer"}}, "enum": [1, 2]}`)
+	b := []byte(`{"properties": {"name": {"type": "string"}}, "enum": [1, 3]}`)
+	breaking, nonBreaking, err := deep.EqualSchema(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Removed property and enum value 2; added property and enum value 3
+	if len(breaking) != 2 || len(nonBreaking) != 2 {
+		t.Errorf("got %d breaking and %d non-breaking changes: %s, %s", len(breaking), len(nonBreaking), breaking, nonBreaking)
+	}
+
+	// Top-level diff
+	breaking, nonBreaking, err = deep.EqualSchema([]byte(`true`), []byte(`false`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(breaking) != 1 || len(nonBreaking) != 0 {
+		t.Errorf("got %d breaking and %d non-breaking changes: %s, %s", len(breaking), len(nonBreaking), breaking, nonBreaking)
+	}
+}
+
+func TestEqualSchemaKeywordNames(t *testing.T) {
+	// Properties and enum values named like documentation keywords
+	a := []byte(`{"properties": {"title": {"type": "string", "description": "a"}}, "enum": ["summary"]}`)
+	b := []byte(`{"properties": {"name": {"type": "string"}}, "enum": []}`)
+	breaking, nonBreaking, err := deep.EqualSchema(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{
+		"map[enum].map[summary]: true != <does not have key>",
+		"map[properties].map[title]: map[description:a type:string] != <does not have key>",
+	}
+	if len(breaking) != len(expect) || len(nonBreaking) != 1 {
+		t.Fatalf("got %d breaking and %d non-breaking changes: %s, %s", len(breaking), len(nonBreaking), breaking, nonBreaking)
+	}
+	for i := range expect {
+		if breaking[i] != expect[i] {
+			t.Errorf("got %q, expected %q", breaking[i], expect[i])
+		}
+	}
+
+	// Documentation of a property named like a keyword
+	b = []byte(`{"properties": {"title": {"type": "string", "description": "b"}}, "enum": ["summary"]}`)
+	breaking, nonBreaking, err = deep.EqualSchema(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(breaking) != 0 || len(nonBreaking) != 1 {
+		t.Errorf("got %d breaking and %d non-breaking changes: %s, %s", len(breaking), len(nonBreaking), breaking, nonBreaking)
+	}
+
+	// Not truncated to MaxDiff
+	props := func(typ string) []byte {
+		s := `{"properties": {`
+		for i := 0; i < 20; i++ {
+			if i > 0 {
+				s += ","
+			}
+			s += fmt.Sprintf(`"p%d": {"type": %q}`, i, typ)
+		}
+		return []byte(s + "}}")
+	}
+	breaking, _, err = deep.EqualSchema(props("string"), props("integer"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(breaking) != 20 {
+		t.Errorf("got %d breaking changes, expected 20", len(breaking))
+	}
+}
@@ -0,0 +1,129 @@
+package deep
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// semver is a parsed semantic version, like "v1.2.3-rc.1".
+type semver struct {
+	major, minor, patch int
+	pre                 []string // prerelease identifiers, like "rc", "1"
+}
+
+// parseSemver parses semantic version s, with an optional "v" prefix. A
+// missing minor or patch version is 0, like "1.2" for "1.2.0", and build
+// metadata, like "+build.5", is ignored.
+func parseSemver(s string) (semver, bool) {
+	s = strings.TrimPrefix(s, "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	var v semver
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		v.pre = strings.Split(s[i+1:], ".")
+		s = s[:i]
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return v, false
+	}
+	nums := []*int{&v.major, &v.minor, &v.patch}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 || p[0] == '+' {
+			return v, false
+		}
+		*nums[i] = n
+	}
+	return v, true
+}
+
+// compareSemver returns -1, 0, or 1 if a is older than, the same as, or newer
+// than b. A prerelease is older than its release, like "1.0.0-rc.1" and
+// "1.0.0".
+func compareSemver(a, b semver) int {
+	for _, d := range []int{a.major - b.major, a.minor - b.minor, a.patch - b.patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+	switch {
+	case len(a.pre) == 0 && len(b.pre) == 0:
+		return 0
+	case len(a.pre) == 0:
+		return 1
+	case len(b.pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(a.pre) && i < len(b.pre); i++ {
+		x, xErr := strconv.Atoi(a.pre[i])
+		y, yErr := strconv.Atoi(b.pre[i])
+		switch {
+		case xErr == nil && yErr == nil:
+			if x != y {
+				return sign(x - y)
+			}
+		case xErr == nil:
+			return -1 // numeric identifiers are older
+		case yErr == nil:
+			return 1
+		case a.pre[i] != b.pre[i]:
+			return strings.Compare(a.pre[i], b.pre[i])
+		}
+	}
+	return sign(len(a.pre) - len(b.pre))
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// Semver is a Matcher for strings that are equal if they are the same
+// semantic version, like "v1.2.3" and "1.2.3", or "1.2" and "1.2.0". Build
+// metadata, like "+build.5", is ignored. Values that are not semantic
+// versions are equal only if they are deeply equal. To compare a struct field
+// as a semantic version, use the tag `deep:"semver"`.
+func Semver(a, b interface{}) bool {
+	as, aOK := a.(string)
+	bs, bOK := b.(string)
+	if aOK && bOK {
+		if av, ok := parseSemver(as); ok {
+			if bv, ok := parseSemver(bs); ok {
+				return compareSemver(av, bv) == 0
+			}
+		}
+	}
+	return Equal(a, b) == nil
+}
+
+// equalsSemver compares strings a and b as semantic versions. If they are
+// different, the diff says which is older, like
+// "Version: a < b: 1.9.0 != 1.10.0". If either is not a semantic version,
+// they are compared normally.
+func (c *cmp) equalsSemver(a, b reflect.Value, level int) {
+	if a.Kind() != reflect.String {
+		c.logError(ErrNotHandled)
+		c.equals(a, b, level)
+		return
+	}
+	av, aOK := parseSemver(a.String())
+	bv, bOK := parseSemver(b.String())
+	if !aOK || !bOK {
+		c.equals(a, b, level)
+		return
+	}
+	switch compareSemver(av, bv) {
+	case -1:
+		c.saveNote("a < b", a.String(), b.String())
+	case 1:
+		c.saveNote("a > b", a.String(), b.String())
+	}
+}
@@ -0,0 +1,57 @@
+package deep_test
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestSemverTag(t *testing.T) {
+	type T struct {
+		Version string `deep:"semver"`
+		Name    string
+	}
+	tests := []struct {
+		a, b string
+		diff string
+	}{
+		{"v1.2.3", "1.2.3", ""},
+		{"1.2", "1.2.0", ""},
+		{"1.2.3+build.1", "1.2.3+build.2", ""},
+		{"1.9.0", "1.10.0", "Version: a < b: 1.9.0 != 1.10.0"},
+		{"2.0.0", "1.10.0", "Version: a > b: 2.0.0 != 1.10.0"},
+		{"1.0.0-rc.1", "1.0.0", "Version: a < b: 1.0.0-rc.1 != 1.0.0"},
+		{"1.0.0-rc.2", "1.0.0-rc.10", "Version: a < b: 1.0.0-rc.2 != 1.0.0-rc.10"},
+		{"1.0.0-alpha", "1.0.0-alpha.1", "Version: a < b: 1.0.0-alpha != 1.0.0-alpha.1"},
+		{"latest", "v1.0.0", "Version: latest != v1.0.0"},
+	}
+	for _, test := range tests {
+		diff := deep.Equal(T{Version: test.a}, T{Version: test.b})
+		if test.diff == "" {
+			if diff != nil {
+				t.Errorf("%s, %s: got %v", test.a, test.b, diff)
+			}
+			continue
+		}
+		if len(diff) != 1 || diff[0] != test.diff {
+			t.Errorf("%s, %s: got %v, expected %s", test.a, test.b, diff, test.diff)
+		}
+	}
+}
+
+func TestSemverMatcher(t *testing.T) {
+	type dep struct {
+		Name    string
+		Version string
+	}
+	a := []dep{{"x", "v1.2.3"}, {"y", "2.0"}}
+	b := []dep{{"x", "1.2.3"}, {"y", "2.0.0"}}
+	if diff := deep.Equal(a, b, deep.WithMatcher("slice[*].Version", deep.Semver)); diff != nil {
+		t.Error(diff)
+	}
+	b[1].Version = "2.1.0"
+	diff := deep.Equal(a, b, deep.WithMatcher("slice[*].Version", deep.Semver))
+	if len(diff) != 1 || diff[0] != "slice[1].Version: 2.0 != 2.1.0" {
+		t.Errorf("got %v", diff)
+	}
+}
@@ -0,0 +1,65 @@
+package deep
+
+import "reflect"
+
+// equalsSet compares slices a and b as sets: the order and number of equal
+// elements are ignored. Elements in a that are not in b are reported like
+// "Tags: x != <missing element>", and elements in b that are not in a like
+// "Tags: <extra element> != y". Elements are compared deeply, with every
+// element of one slice against every element of the other, so this is slow
+// for large slices.
+func (c *cmp) equalsSet(a, b reflect.Value, level int) {
+	if a.Kind() != reflect.Slice {
+		c.logError(ErrNotHandled)
+		c.equals(a, b, level)
+		return
+	}
+	aSet, bSet := c.distinct(a, level), c.distinct(b, level)
+	for _, x := range aSet {
+		if !c.contains(bSet, x, level) {
+			c.saveDiff(x, marker("<missing element>"))
+			if c.full() {
+				return
+			}
+		}
+	}
+	for _, y := range bSet {
+		if !c.contains(aSet, y, level) {
+			c.saveDiff(marker("<extra element>"), y)
+			if c.full() {
+				return
+			}
+		}
+	}
+}
+
+// distinct returns the distinct elements of slice s in order.
+func (c *cmp) distinct(s reflect.Value, level int) []reflect.Value {
+	var set []reflect.Value
+	for i := 0; i < s.Len(); i++ {
+		if v := s.Index(i); !c.contains(set, v, level) {
+			set = append(set, v)
+		}
+	}
+	return set
+}
+
+// contains returns true if set has an element equal to v.
+func (c *cmp) contains(set []reflect.Value, v reflect.Value, level int) bool {
+	for _, x := range set {
+		if c.equal(x, v, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// equal returns true if a and b are equal, without saving diffs.
+func (c *cmp) equal(a, b reflect.Value, level int) bool {
+	f := c.fork()
+	f.maxDiff = 1
+	f.onCompare = nil
+	f.serial = true
+	f.equals(a, b, level)
+	return len(f.diff) == 0
+}
@@ -0,0 +1,47 @@
+package deep_test
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestSetTag(t *testing.T) {
+	type item struct {
+		ID   int
+		Tags []string
+	}
+	type T struct {
+		Tags  []string `deep:"set"`
+		Items []item   `deep:"set"`
+	}
+	a := T{
+		Tags:  []string{"a", "b", "a"},
+		Items: []item{{1, []string{"x"}}, {2, nil}},
+	}
+	b := T{
+		Tags:  []string{"b", "a"},
+		Items: []item{{2, nil}, {1, []string{"x"}}, {2, nil}},
+	}
+	if diff := deep.Equal(a, b); diff != nil {
+		t.Error(diff)
+	}
+
+	b.Tags = []string{"b", "c", "c"}
+	b.Items[1].Tags = []string{"y"}
+	diff := deep.Equal(a, b)
+	want := []string{
+		"Tags: a != <missing element>",
+		"Tags: <extra element> != c",
+		"Items: {1 [x]} != <missing element>",
+		"Items: <extra element> != {1 [y]}",
+	}
+	if len(diff) != len(want) {
+		t.Fatalf("got %d diffs, expected %d: %v", len(diff), len(want), diff)
+	}
+	for i := range want {
+		if diff[i] != want[i] {
+			t.Errorf("got %q, expected %q", diff[i], want[i])
+		}
+	}
+}
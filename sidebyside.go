@@ -0,0 +1,112 @@
+package deep
+
+import (
+	"strings"
+)
+
+// SideBySide returns Dump of a and b printed side by side, aligned by path,
+// with lines that differ marked with "!". Fields, keys, and elements that
+// only one value has are printed across from a blank line. For example:
+//
+//	  Name: foo          | Name: foo
+//	! Age: 30            | Age: 31
+//	  Tags:              | Tags:
+//	!   slice[0]: a      |
+//
+// Values are compared like Equal(a, b, flags...), so it is useful for seeing
+// diffs in the context of the whole values. Fields that Equal skips if they are
+// zero, with IgnoreZeroFields or the tag `deep:"optional"`, are printed but not
+// marked.
+func SideBySide(a, b interface{}, flags ...interface{}) string {
+	c := newCmp(flags)
+	defer c.release()
+	c.compare(a, b)
+	ca, cb := newCmp(flags), newCmp(flags)
+	defer ca.release()
+	defer cb.release()
+	aLines := dumpLines(a, ca, true)
+	bLines := dumpLines(b, cb, true)
+
+	// Merge the lines by path, keeping the order of both
+	key := func(l dumpLine) string { return strings.Join(l.path, "\x00") }
+	inA := map[string]bool{}
+	for _, l := range aLines {
+		inA[key(l)] = true
+	}
+	inB := map[string]bool{}
+	for _, l := range bLines {
+		inB[key(l)] = true
+	}
+	type row struct {
+		a, b *dumpLine
+	}
+	var rows []row
+	i, j := 0, 0
+	for i < len(aLines) || j < len(bLines) {
+		switch {
+		case i < len(aLines) && j < len(bLines) && key(aLines[i]) == key(bLines[j]):
+			rows = append(rows, row{&aLines[i], &bLines[j]})
+			i++
+			j++
+		case i < len(aLines) && (j == len(bLines) || !inB[key(aLines[i])] || inA[key(bLines[j])]):
+			rows = append(rows, row{a: &aLines[i]})
+			i++
+		default:
+			rows = append(rows, row{b: &bLines[j]})
+			j++
+		}
+	}
+
+	width := 0
+	for _, r := range rows {
+		if r.a != nil && len(r.a.String()) > width {
+			width = len(r.a.String())
+		}
+	}
+	var out strings.Builder
+	for _, r := range rows {
+		var as, bs string
+		line := r.a
+		if line != nil {
+			as = line.String()
+		}
+		if r.b != nil {
+			bs = r.b.String()
+			line = r.b
+		}
+		if as == "" && bs == "" {
+			continue // root parent
+		}
+		mark := "  "
+		if r.a == nil || r.b == nil || c.differs(line.path, line.parent) {
+			mark = "! "
+		}
+		out.WriteString(strings.TrimRight(mark+as+strings.Repeat(" ", width-len(as))+" | "+bs, " "))
+		out.WriteString("\n")
+	}
+	return out.String()
+}
+
+// differs returns true if there is a diff at path, above it, or, if the path
+// is not a parent, below it.
+func (c *cmp) differs(path []string, parent bool) bool {
+	for _, d := range c.details {
+		if hasPathPrefix(path, d.Path) || (!parent && hasPathPrefix(d.Path, path)) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPathPrefix returns true if path starts with prefix.
+func hasPathPrefix(path, prefix []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,61 @@
+package deep_test
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestSideBySide(t *testing.T) {
+	type User struct {
+		Name string
+		Age  int
+		Tags []string
+	}
+	a := User{"foo", 30, []string{"a", "b"}}
+	b := User{"foo", 31, []string{"a"}}
+	expect := "" +
+		"  Name: foo     | Name: foo\n" +
+		"! Age: 30       | Age: 31\n" +
+		"  Tags:         | Tags:\n" +
+		"    slice[0]: a |   slice[0]: a\n" +
+		"!   slice[1]: b |\n"
+	if got := deep.SideBySide(a, b); got != expect {
+		t.Errorf("got:\n%s\nexpected:\n%s", got, expect)
+	}
+
+	// Scalars
+	if got := deep.SideBySide(1, 2); got != "! 1 | 2\n" {
+		t.Errorf("got %q", got)
+	}
+	if got := deep.SideBySide("x", "x"); got != "  x | x\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestSideBySideSkippedFields(t *testing.T) {
+	type T struct {
+		Name     string
+		ID       int
+		Optional int `deep:"optional"`
+	}
+	a := T{Name: "foo", ID: 1}
+	b := T{Name: "bar", ID: 2, Optional: 3}
+	expect := "" +
+		"! Name: foo   | Name: bar\n" +
+		"  Optional: 0 | Optional: 3\n"
+	if got := deep.SideBySide(a, b, deep.IgnorePaths("ID")); got != expect {
+		t.Errorf("got:\n%s\nexpected:\n%s", got, expect)
+	}
+
+	// Cycles
+	type Node struct {
+		Next *Node
+	}
+	n := &Node{}
+	n.Next = n
+	expect = "  Next: <cycle> | Next: <cycle>\n"
+	if got := deep.SideBySide(n, n); got != expect {
+		t.Errorf("got:\n%s\nexpected:\n%s", got, expect)
+	}
+}
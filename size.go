@@ -0,0 +1,82 @@
+package deep
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+)
+
+// summarizeValue returns v summarized by its type, length, and hash if its
+// estimated size is greater than SummarizeValueSize, else it returns false.
+func summarizeValue(v interface{}) (string, bool) {
+	if SummarizeValueSize <= 0 {
+		return "", false
+	}
+	rv, ok := v.(reflect.Value)
+	if !ok {
+		rv = reflect.ValueOf(v)
+	}
+	if !rv.IsValid() || estimateSize(rv, SummarizeValueSize, 0) <= SummarizeValueSize {
+		return "", false
+	}
+
+	h := sha256.New()
+	switch {
+	case rv.Kind() == reflect.String:
+		h.Write([]byte(rv.String()))
+	case rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8:
+		h.Write(rv.Bytes())
+	default:
+		fmt.Fprintf(h, "%v", v)
+	}
+	sum := hex.EncodeToString(h.Sum(nil)[:4])
+
+	switch rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map, reflect.Chan:
+		return fmt.Sprintf("<%s len=%d sha256=%s>", rv.Type(), rv.Len(), sum), true
+	}
+	return fmt.Sprintf("<%s sha256=%s>", rv.Type(), sum), true
+}
+
+// estimateSize returns the estimated size of v printed with %v. It stops
+// estimating when the size is greater than limit.
+func estimateSize(v reflect.Value, limit, depth int) int {
+	if depth > 100 {
+		return limit + 1 // probably a cycle
+	}
+	switch v.Kind() {
+	case reflect.Invalid:
+		return 5 // <nil>
+	case reflect.String:
+		return v.Len()
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return 5
+		}
+		return estimateSize(v.Elem(), limit, depth+1)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Len() * 4 // like [255 0 ...]
+		}
+		n := 2
+		for i := 0; i < v.Len() && n <= limit; i++ {
+			n += estimateSize(v.Index(i), limit, depth+1) + 1
+		}
+		return n
+	case reflect.Map:
+		n := 5
+		iter := v.MapRange()
+		for iter.Next() && n <= limit {
+			n += estimateSize(iter.Key(), limit, depth+1) + estimateSize(iter.Value(), limit, depth+1) + 2
+		}
+		return n
+	case reflect.Struct:
+		n := 2
+		for i := 0; i < v.NumField() && n <= limit; i++ {
+			n += estimateSize(v.Field(i), limit, depth+1) + 1
+		}
+		return n
+	}
+	return 8 // numbers, bools, and so on
+}
@@ -0,0 +1,51 @@
+package deep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestSummarizeValueSize(t *testing.T) {
+	type Blob struct {
+		Name string
+		Data []byte
+		Text string
+	}
+	a := Blob{"a", make([]byte, 1<<20), strings.Repeat("x", 1<<20)}
+	b := Blob{"b", make([]byte, 1<<20), strings.Repeat("y", 1<<20)}
+	b.Data[0] = 1
+
+	deep.SummarizeValueSize = 1024
+	defer func() { deep.SummarizeValueSize = 0 }()
+
+	diff := deep.Equal(a, b)
+	if len(diff) != 3 {
+		t.Fatalf("expected 3 diff, got %d", len(diff))
+	}
+	if diff[0] != "Name: a != b" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+	if diff[1] != "Data.slice[0]: 0 != 1" {
+		t.Errorf("wrong diff: %s", diff[1])
+	}
+	if !strings.HasPrefix(diff[2], "Text: <string len=1048576 sha256=") || len(diff[2]) > 100 {
+		t.Errorf("wrong diff: %.100s", diff[2])
+	}
+
+	// Summaries of different values have different hashes
+	parts := strings.Split(diff[2], " != ")
+	if len(parts) != 2 || parts[0][len("Text: "):] == parts[1] {
+		t.Errorf("wrong diff: %.200s", diff[2])
+	}
+
+	// Missing map values
+	diff = deep.Equal(map[string][]byte{"k": a.Data}, map[string][]byte{})
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d", len(diff))
+	}
+	if !strings.HasPrefix(diff[0], "map[k]: <[]uint8 len=1048576 sha256=") || !strings.HasSuffix(diff[0], " != <does not have key>") {
+		t.Errorf("wrong diff: %.100s", diff[0])
+	}
+}
@@ -0,0 +1,86 @@
+package deep
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// lessFuncs are the registered less funcs by type; see RegisterLess.
+var lessFuncs = map[reflect.Type]reflect.Value{}
+
+// RegisterLess registers less, a func(T, T) bool that returns true if the
+// first value sorts before the second, to sort slices of T in struct fields
+// with the tag `deep:"sorted"`. Slices with elements of a number or string
+// kind are sorted in their natural order without registering a func. A less
+// func registered for T takes precedence; see UnregisterLess.
+//
+// RegisterLess panics if less is not a func(T, T) bool. It is not safe to call
+// concurrently with Equal.
+func RegisterLess(less interface{}) {
+	v := reflect.ValueOf(less)
+	t := v.Type()
+	if t.Kind() != reflect.Func || t.NumIn() != 2 || t.In(0) != t.In(1) ||
+		t.NumOut() != 1 || t.Out(0).Kind() != reflect.Bool {
+		panic(fmt.Sprintf("deep: RegisterLess: %s is not a func(T, T) bool", t))
+	}
+	lessFuncs[t.In(0)] = v
+}
+
+// UnregisterLess unregisters the less func for type t; see RegisterLess.
+func UnregisterLess(t reflect.Type) {
+	delete(lessFuncs, t)
+}
+
+// lessFunc returns a func that returns true if element i of slice s sorts
+// before element j, or false if the elements cannot be sorted.
+func lessFunc(s reflect.Value) (func(i, j int) bool, bool) {
+	if fn, ok := lessFuncs[s.Type().Elem()]; ok {
+		return func(i, j int) bool {
+			return fn.Call([]reflect.Value{s.Index(i), s.Index(j)})[0].Bool()
+		}, true
+	}
+	switch s.Type().Elem().Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(i, j int) bool { return s.Index(i).Int() < s.Index(j).Int() }, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(i, j int) bool { return s.Index(i).Uint() < s.Index(j).Uint() }, true
+	case reflect.Float32, reflect.Float64:
+		return func(i, j int) bool { return s.Index(i).Float() < s.Index(j).Float() }, true
+	case reflect.String:
+		return func(i, j int) bool { return s.Index(i).String() < s.Index(j).String() }, true
+	}
+	return nil, false
+}
+
+// sortedCopy returns a sorted copy of slice s, or false if it cannot be sorted.
+func sortedCopy(s reflect.Value) (reflect.Value, bool) {
+	if s.Kind() != reflect.Slice || !s.CanInterface() || s.IsNil() {
+		return s, false
+	}
+	sorted := reflect.MakeSlice(s.Type(), s.Len(), s.Len())
+	reflect.Copy(sorted, s)
+	less, ok := lessFunc(sorted)
+	if !ok {
+		return s, false
+	}
+	sort.SliceStable(sorted.Interface(), less)
+	return sorted, true
+}
+
+// equalsSorted compares slices a and b after sorting copies of them, so the
+// order of elements is ignored. If either slice cannot be sorted, they are
+// compared normally.
+func (c *cmp) equalsSorted(a, b reflect.Value, level int) {
+	if a.Kind() != reflect.Slice {
+		c.logError(ErrNotHandled)
+		c.equals(a, b, level)
+		return
+	}
+	if sa, ok := sortedCopy(a); ok {
+		if sb, ok := sortedCopy(b); ok {
+			a, b = sa, sb
+		}
+	}
+	c.equals(a, b, level)
+}
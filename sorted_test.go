@@ -0,0 +1,79 @@
+package deep_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+type version struct {
+	Major, Minor int
+}
+
+func TestSortedTag(t *testing.T) {
+	type T struct {
+		Names    []string  `deep:"sorted"`
+		Counts   []int     `deep:"sorted"`
+		Versions []version `deep:"sorted"`
+		Ordered  []string
+	}
+	a := T{
+		Names:    []string{"b", "a", "c"},
+		Counts:   []int{3, 1, 2},
+		Versions: []version{{1, 2}, {1, 0}},
+		Ordered:  []string{"x", "y"},
+	}
+	b := T{
+		Names:    []string{"c", "b", "a"},
+		Counts:   []int{1, 2, 3},
+		Versions: []version{{1, 0}, {1, 2}},
+		Ordered:  []string{"x", "y"},
+	}
+
+	// Versions cannot be sorted without a less func, so compared by order
+	diff := deep.Equal(a, b)
+	if len(diff) != 2 {
+		t.Fatalf("expected 2 diffs, got %d: %v", len(diff), diff)
+	}
+	if diff[0] != "Versions.slice[0].Minor: 2 != 0" {
+		t.Errorf("got %q", diff[0])
+	}
+
+	deep.RegisterLess(func(x, y version) bool {
+		return x.Major < y.Major || (x.Major == y.Major && x.Minor < y.Minor)
+	})
+	defer deep.UnregisterLess(reflect.TypeOf(version{}))
+	if diff := deep.Equal(a, b); diff != nil {
+		t.Error(diff)
+	}
+	if a.Names[0] != "b" || b.Names[0] != "c" {
+		t.Error("values modified")
+	}
+
+	b.Counts = []int{1, 2, 4}
+	b.Ordered = []string{"y", "x"}
+	diff = deep.Equal(a, b)
+	want := []string{
+		"Counts.slice[2]: 3 != 4",
+		"Ordered.slice[0]: x != y",
+		"Ordered.slice[1]: y != x",
+	}
+	if len(diff) != len(want) {
+		t.Fatalf("got %d diffs, expected %d: %v", len(diff), len(want), diff)
+	}
+	for i := range want {
+		if diff[i] != want[i] {
+			t.Errorf("got %q, expected %q", diff[i], want[i])
+		}
+	}
+}
+
+func TestRegisterLessPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("RegisterLess did not panic")
+		}
+	}()
+	deep.RegisterLess(func(x, y int) int { return x - y })
+}
@@ -0,0 +1,110 @@
+package deep
+
+import (
+	"strings"
+	"unicode"
+)
+
+// sqlKeywords are the keywords that EqualSQL compares case-insensitively.
+var sqlKeywords = map[string]bool{}
+
+func init() {
+	for _, kw := range strings.Fields(`
+		ADD ALL ALTER AND ANY AS ASC BETWEEN BY CASE CAST COLUMN CONFLICT
+		CONSTRAINT CREATE CROSS DEFAULT DELETE DESC DISTINCT DO DROP ELSE END
+		EXCEPT EXISTS FALSE FETCH FIRST FOR FOREIGN FROM FULL GROUP HAVING
+		ILIKE IN INDEX INNER INSERT INTERSECT INTO IS JOIN KEY LEFT LIKE LIMIT
+		NEXT NOT NOTHING NULL OFFSET ON ONLY OR ORDER OUTER OVER PARTITION
+		PRIMARY REFERENCES RETURNING RIGHT ROWS SELECT SET TABLE THEN TRUE
+		UNION UNIQUE UPDATE USING VALUES VIEW WHEN WHERE WITH`) {
+		sqlKeywords[kw] = true
+	}
+}
+
+// sqlQuery is a tokenized SQL query compared by EqualSQL.
+type sqlQuery struct {
+	Tokens []string
+}
+
+// EqualSQL compares SQL queries a and b, like queries generated by an ORM or
+// query builder, and returns a list of differences, or nil if there are none.
+// The queries are compared token by token, ignoring whitespace, comments, a
+// trailing semicolon, and the case of common keywords like SELECT and WHERE.
+// Quoted strings and identifiers, and unquoted identifiers, are compared
+// exactly. Differences are reported by token index, like
+// "Tokens.slice[3]: users != accounts". The comparison is lexical; EqualSQL
+// does not parse the queries, so equivalent but differently written queries,
+// like "a = 1 AND b = 2" and "b = 2 AND a = 1", are not equal.
+func EqualSQL(a, b string) []string {
+	return Equal(tokenizeSQL(a), tokenizeSQL(b))
+}
+
+// tokenizeSQL splits query into normalized tokens.
+func tokenizeSQL(query string) sqlQuery {
+	q := sqlQuery{Tokens: []string{}}
+	s := []rune(query)
+	for i := 0; i < len(s); {
+		r := s[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < len(s) && s[i+1] == '-':
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(s) && s[i+1] == '*':
+			i += 2
+			for i < len(s) && !(s[i] == '*' && i+1 < len(s) && s[i+1] == '/') {
+				i++
+			}
+			i += 2
+		case r == '\'' || r == '"' || r == '`':
+			// Quoted string or identifier, a doubled quote is an escaped quote
+			j := i + 1
+			for j < len(s) {
+				if s[j] == r {
+					if j+1 < len(s) && s[j+1] == r {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			if j < len(s) {
+				j++
+			}
+			q.Tokens = append(q.Tokens, string(s[i:j]))
+			i = j
+		case isSQLWordRune(r):
+			j := i
+			for j < len(s) && isSQLWordRune(s[j]) {
+				j++
+			}
+			word := string(s[i:j])
+			if upper := strings.ToUpper(word); sqlKeywords[upper] {
+				word = upper
+			}
+			q.Tokens = append(q.Tokens, word)
+			i = j
+		default:
+			tok := string(r)
+			if i+1 < len(s) {
+				switch two := string(s[i : i+2]); two {
+				case "<=", ">=", "<>", "!=", "||", "::", "->":
+					tok = two
+				}
+			}
+			q.Tokens = append(q.Tokens, tok)
+			i += len([]rune(tok))
+		}
+	}
+	if n := len(q.Tokens); n > 0 && q.Tokens[n-1] == ";" {
+		q.Tokens = q.Tokens[:n-1]
+	}
+	return q
+}
+
+func isSQLWordRune(r rune) bool {
+	return r == '_' || r == '$' || r == '@' || r == '?' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,40 @@
+package deep_test
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestEqualSQL(t *testing.T) {
+	a := `SELECT id, name FROM users WHERE name = 'O''Brien' AND age>=18;`
+	b := `select id,name
+		from users -- active users
+		/* filter */ where name = 'O''Brien' and age >= 18`
+	diff := deep.EqualSQL(a, b)
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+
+	// Identifiers and strings are case-sensitive
+	b = `SELECT id, name FROM accounts WHERE name = 'o''brien' AND age >= 18`
+	diff = deep.EqualSQL(a, b)
+	expect := []string{
+		"Tokens.slice[5]: users != accounts",
+		"Tokens.slice[9]: 'O''Brien' != 'o''brien'",
+	}
+	if len(diff) != len(expect) {
+		t.Fatalf("expected %d diff, got %d: %s", len(expect), len(diff), diff)
+	}
+	for i := range expect {
+		if diff[i] != expect[i] {
+			t.Errorf("got '%s', expected '%s'", diff[i], expect[i])
+		}
+	}
+
+	// Placeholders
+	diff = deep.EqualSQL(`UPDATE t SET a = $1 WHERE b = ?`, `update t set a=$1 where b=?`)
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+}
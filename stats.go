@@ -0,0 +1,99 @@
+package deep
+
+import (
+	"errors"
+	"reflect"
+)
+
+// DiffStats are the numbers of differences by category; see Diff.Stats.
+type DiffStats struct {
+	// Changed is the number of values that differ, including summary diffs,
+	// like "slice[5..9]: 5 elements differ".
+	Changed int
+
+	// MissingKeys is the number of map keys in only one map, like
+	// "map[foo]: <does not have key> != 1".
+	MissingKeys int
+
+	// ExtraElements is the number of slice elements in only one slice, like
+	// "slice[2]: 3 != <no value>", including unmatched elements of slices
+	// compared in any order.
+	ExtraElements int
+
+	// TypeMismatches is the number of values of different types, like
+	// "int != string".
+	TypeMismatches int
+
+	// Skipped is the number of values that were not compared, like funcs and
+	// channels, or values below MaxDepth; see Diff.Warnings.
+	Skipped int
+}
+
+// category is the category of a difference; see DiffStats.
+type category uint8
+
+const (
+	changed category = iota
+	missingKey
+	extraElement
+	typeMismatch
+)
+
+// String returns the category as it is printed by Diff.WriteCSV, like
+// "missing key".
+func (c category) String() string {
+	switch c {
+	case missingKey:
+		return "missing key"
+	case extraElement:
+		return "extra element"
+	case typeMismatch:
+		return "type mismatch"
+	}
+	return "changed"
+}
+
+// diffCategory returns the category of a difference between a and b as they
+// are passed to saveDiff.
+func diffCategory(a, b interface{}) category {
+	if _, ok := a.(reflect.Type); ok {
+		if _, ok := b.(reflect.Type); ok {
+			return typeMismatch
+		}
+	}
+	for _, v := range []interface{}{a, b} {
+		switch v {
+		case marker("<does not have key>"):
+			return missingKey
+		case marker("<no value>"), marker("<missing element>"), marker("<extra element>"), marker("<no match>"):
+			return extraElement
+		}
+	}
+	return changed
+}
+
+// Stats returns the numbers of differences by category, like changed values
+// and missing map keys, for charting what kind of differences occur. Collapsed
+// differences (see DedupDiffs) are counted once per path.
+func (d Diff) Stats() DiffStats {
+	var s DiffStats
+	for _, diff := range d.Differences {
+		n := 1 + diff.Duplicates
+		switch diff.category {
+		case missingKey:
+			s.MissingKeys += n
+		case extraElement:
+			s.ExtraElements += n
+		case typeMismatch:
+			s.TypeMismatches += n
+		default:
+			s.Changed += n
+		}
+	}
+	for _, w := range d.warnings {
+		if errors.Is(w.Err, ErrNotHandled) || errors.Is(w.Err, ErrMaxRecursion) || errors.Is(w.Err, ErrMaxOps) {
+			s.Skipped++
+		}
+	}
+	return s
+}
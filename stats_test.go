@@ -0,0 +1,42 @@
+package deep_test
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestDiffStats(t *testing.T) {
+	type T struct {
+		Name   string
+		Labels map[string]string
+		Items  []int
+		Value  interface{}
+		Run    func()
+	}
+	a := T{
+		Name:   "a",
+		Labels: map[string]string{"x": "1", "y": "2"},
+		Items:  []int{1, 2, 3},
+		Value:  1,
+		Run:    func() {},
+	}
+	b := T{
+		Name:   "b",
+		Labels: map[string]string{"x": "1", "z": "3"},
+		Items:  []int{1, 2},
+		Value:  "1",
+		Run:    func() {},
+	}
+	got := deep.Compare(a, b).Stats()
+	want := deep.DiffStats{
+		Changed:        1,
+		MissingKeys:    2,
+		ExtraElements:  1,
+		TypeMismatches: 1,
+		Skipped:        1,
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+}
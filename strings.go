@@ -0,0 +1,48 @@
+package deep
+
+import (
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+)
+
+// saveLongStrings saves a diff of strings a and b, which differ, with the
+// offset of the first difference and StringContext bytes of context around
+// it, not splitting runes.
+func (c *cmp) saveLongStrings(a, b string) {
+	offset := 0
+	for offset < len(a) && offset < len(b) && a[offset] == b[offset] {
+		offset++
+	}
+	for offset > 0 && offset < len(a) && !utf8.RuneStart(a[offset]) {
+		offset-- // start of the rune that differs
+	}
+	note := fmt.Sprintf("string differs at offset %d", offset)
+	c.saveNote(note, quoted(stringWindow(a, offset)), quoted(stringWindow(b, offset)))
+}
+
+// stringWindow returns s from StringContext bytes before offset to
+// StringContext bytes after it, quoted, with "..." where s is cut.
+func stringWindow(s string, offset int) string {
+	start, end := offset-StringContext, offset+StringContext+1
+	if start < 0 {
+		start = 0
+	}
+	for start > 0 && !utf8.RuneStart(s[start]) {
+		start--
+	}
+	if end > len(s) {
+		end = len(s)
+	}
+	for end < len(s) && !utf8.RuneStart(s[end]) {
+		end++
+	}
+	w := s[start:end]
+	if start > 0 {
+		w = "..." + w
+	}
+	if end < len(s) {
+		w += "..."
+	}
+	return strconv.Quote(w)
+}
@@ -0,0 +1,45 @@
+package deep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestStringContext(t *testing.T) {
+	defer func(n int) { deep.StringContext = n }(deep.StringContext)
+	deep.StringContext = 3
+
+	a := strings.Repeat("x", 1000) + "abcXdef" + strings.Repeat("y", 1000)
+	b := strings.Repeat("x", 1000) + "abcYdef" + strings.Repeat("y", 1000)
+	diff := deep.Equal(a, b)
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %v", len(diff), diff)
+	}
+	if diff[0] != `string differs at offset 1003: "...abcXdef..." != "...abcYdef..."` {
+		t.Error("wrong diff:", diff[0])
+	}
+
+	// Start, end, and different lengths
+	for _, v := range []struct {
+		a, b   string
+		expect string
+	}{
+		{"Xbcdefghij", "Ybcdefghij", `string differs at offset 0: "Xbcd..." != "Ybcd..."`},
+		{"abcdefghiX", "abcdefghiY", `string differs at offset 9: "...ghiX" != "...ghiY"`},
+		{"abcdefghij", "abcdefghijklm", `string differs at offset 10: "...hij" != "...hijklm"`},
+		// Runes are not split
+		{"abcdefgh✓", "abcdefgh✗", `string differs at offset 8: "...fgh✓" != "...fgh✗"`},
+		// Short strings are printed in full
+		{"abc", "abd", "abc != abd"},
+	} {
+		diff := deep.Equal(v.a, v.b)
+		if len(diff) != 1 {
+			t.Fatalf("expected 1 diff, got %d: %v", len(diff), diff)
+		}
+		if diff[0] != v.expect {
+			t.Errorf("got %s, expected %s", diff[0], v.expect)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package deep
+
+import (
+	"testing"
+)
+
+// RunDiffs runs one failing subtest of t per difference in diff, named by the
+// difference path, like "Address.City", so CI tools show one failure per
+// differing path and go test -run can select one path, like
+// -run 'TestUser/Address.City'. A difference without a path, like "1 != 2", is
+// named "value". RunDiffs does nothing if diff has no differences. For
+// example:
+//
+//	deep.RunDiffs(t, deep.Compare(got, want))
+func RunDiffs(t *testing.T, diff Diff) {
+	t.Helper()
+	for _, d := range diff.Differences {
+		d := d
+		name := "value"
+		if len(d.Path) > 0 {
+			name = joinPath(d.Path)
+		}
+		t.Run(name, func(t *testing.T) {
+			t.Helper()
+			t.Error(d.String())
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package deep_test
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestRunDiffs(t *testing.T) {
+	type Address struct {
+		City, Zip string
+	}
+	type User struct {
+		Name    string
+		Address Address
+	}
+	a := User{"foo", Address{"a", "1"}}
+	b := User{"foo", Address{"a", "1"}}
+	deep.RunDiffs(t, deep.Compare(a, b)) // no subtests when equal
+
+	if os.Getenv("DEEP_TEST_RUN_DIFFS") == "1" {
+		b = User{"bar", Address{"b", "1"}}
+		deep.RunDiffs(t, deep.Compare(a, b))
+		deep.RunDiffs(t, deep.Compare(1, 2))
+		deep.RunDiffs(t, deep.Compare(map[string]int{"a: b": 1}, map[string]int{"a: b": 2}))
+		return
+	}
+
+	// Subtests fail, so run them in a child test process
+	cmd := exec.Command(os.Args[0], "-test.run", "^TestRunDiffs$", "-test.v")
+	cmd.Env = append(os.Environ(), "DEEP_TEST_RUN_DIFFS=1")
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("expected subtests to fail:\n%s", out)
+	}
+	for _, s := range []string{
+		"--- FAIL: TestRunDiffs/Name ",
+		"Name: foo != bar",
+		"--- FAIL: TestRunDiffs/Address.City ",
+		"Address.City: a != b",
+		"--- FAIL: TestRunDiffs/value ",
+		"1 != 2",
+		"--- FAIL: TestRunDiffs/map[a:_b] ",
+		"map[a: b]: 1 != 2",
+	} {
+		if !strings.Contains(string(out), s) {
+			t.Errorf("output does not contain %q:\n%s", s, out)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package deep
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// indexPattern matches slice and array indexes in paths, like "slice[5]" or
+// "array[0..9]".
+var indexPattern = regexp.MustCompile(`^(slice|array)\[[0-9.]+\]$`)
+
+// SuggestIgnorePaths returns IgnorePaths code, like
+// `deep.IgnorePaths("CreatedAt", "Items.slice[*].ID")`, for paths that are
+// volatile across runs: the diffs of every run have a diff at the path, and
+// the diff values are not the same in every run, like timestamps and random
+// IDs. Slice and array indexes are replaced by "*". runs are the diffs of
+// comparing the same values in different runs, for example of a test. At
+// least two runs are needed. An empty string is returned if no paths are
+// volatile.
+func SuggestIgnorePaths(runs ...Diff) string {
+	if len(runs) < 2 {
+		return ""
+	}
+	type path struct {
+		runs   map[int]bool
+		values map[string]map[string]bool // by concrete path
+	}
+	paths := map[string]*path{}
+	for i, run := range runs {
+		for _, d := range run.Differences {
+			general := make([]string, len(d.Path))
+			for j, s := range d.Path {
+				if m := indexPattern.FindStringSubmatch(s); m != nil {
+					s = m[1] + "[*]"
+				}
+				general[j] = s
+			}
+			key := strings.Join(general, ".")
+			if paths[key] == nil {
+				paths[key] = &path{runs: map[int]bool{}, values: map[string]map[string]bool{}}
+			}
+			p := paths[key]
+			p.runs[i] = true
+			concrete := strings.Join(d.Path, ".")
+			if p.values[concrete] == nil {
+				p.values[concrete] = map[string]bool{}
+			}
+			p.values[concrete][d.A+" != "+d.B] = true
+		}
+	}
+
+	var volatile []string
+	for key, p := range paths {
+		if key == "" || len(p.runs) != len(runs) {
+			continue
+		}
+		for _, values := range p.values {
+			if len(values) > 1 {
+				volatile = append(volatile, key)
+				break
+			}
+		}
+	}
+	if len(volatile) == 0 {
+		return ""
+	}
+	sort.Strings(volatile)
+
+	// Remove paths below other volatile paths
+	patterns := volatile[:0]
+	for _, p := range volatile {
+		if n := len(patterns); n > 0 && strings.HasPrefix(p, patterns[n-1]+".") {
+			continue
+		}
+		patterns = append(patterns, p)
+	}
+
+	quoted := make([]string, len(patterns))
+	for i := range patterns {
+		quoted[i] = fmt.Sprintf("%q", patterns[i])
+	}
+	return "deep.IgnorePaths(" + strings.Join(quoted, ", ") + ")"
+}
@@ -0,0 +1,58 @@
+package deep_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+)
+
+func TestSuggestIgnorePaths(t *testing.T) {
+	type Item struct {
+		ID   int
+		Name string
+	}
+	type Order struct {
+		Status    string
+		CreatedAt time.Time
+		Items     []Item
+	}
+	want := Order{Status: "new", Items: []Item{{0, "a"}, {0, "b"}}}
+	var runs []deep.Diff
+	for i := 1; i <= 3; i++ {
+		got := Order{
+			Status:    "pending", // bug, not volatile
+			CreatedAt: time.Date(2024, 1, i, 0, 0, 0, 0, time.UTC),
+			Items:     []Item{{i * 10, "a"}, {i*10 + 1, "b"}},
+		}
+		runs = append(runs, deep.Compare(got, want))
+	}
+
+	code := deep.SuggestIgnorePaths(runs...)
+	expect := `deep.IgnorePaths("CreatedAt", "Items.slice[*].ID")`
+	if code != expect {
+		t.Errorf("got %s, expected %s", code, expect)
+	}
+	if code := deep.SuggestIgnorePaths(runs[0]); code != "" {
+		t.Errorf("expected no suggestion for one run, got %s", code)
+	}
+
+	// Suggested paths are ignored
+	got := Order{Status: "new", CreatedAt: time.Now(), Items: []Item{{1, "a"}, {2, "b"}}}
+	diff := deep.Equal(got, want, deep.IgnorePaths("CreatedAt", "Items.slice[*].ID"))
+	if len(diff) != 0 {
+		t.Errorf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+}
+
+func TestIgnorePaths(t *testing.T) {
+	a := map[string]interface{}{"id": 1, "meta": map[string]int{"rev": 1}, "name": "a"}
+	b := map[string]interface{}{"id": 2, "name": "b"}
+	diff := deep.Equal(a, b, deep.IgnorePaths("map[id]", "map[meta]"))
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "map[name]: a != b" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+}
@@ -0,0 +1,133 @@
+package deep
+
+import (
+	"fmt"
+	"strings"
+)
+
+// elementRun is a run of consecutive slice or array elements whose diffs have
+// the same shape: the same paths relative to the element. Only the diffs of
+// the first element in the run are kept; the others are removed as they are
+// added so they don't count toward MaxDiff. When the run ends, if it has at
+// least SummarizeRepeatedDiffs elements, the diffs of the first element are
+// replaced by one summary diff. Else, the removed diffs are restored.
+type elementRun struct {
+	kind  string // "slice" or "array"
+	first int    // index of the first element
+	n     int    // number of elements
+	start int    // index in cmp.diff of the diffs of the first element
+	shape []string
+
+	// Removed diffs to restore if the run is too short to summarize
+	removedDiff    []string
+	removedDetails []Difference
+}
+
+// add adds element i, whose diffs start at index start in c.diff, to the run.
+func (r *elementRun) add(c *cmp, i, start int) {
+	if SummarizeRepeatedDiffs <= 0 || SampleElementDiffs > 0 {
+		return
+	}
+	shape := c.shape(start)
+	if len(shape) == 0 {
+		r.end(c) // no diffs
+		return
+	}
+	if r.n > 0 && r.first+r.n == i && strings.Join(shape, "\x00") == strings.Join(r.shape, "\x00") {
+		r.n++
+		if r.n < SummarizeRepeatedDiffs {
+			r.removedDiff = append(r.removedDiff, c.diff[start:]...)
+			r.removedDetails = append(r.removedDetails, c.details[start:]...)
+		} else {
+			r.removedDiff, r.removedDetails = nil, nil
+		}
+		c.diff = c.diff[:start]
+		c.details = c.details[:start]
+		return
+	}
+	r.end(c)
+	start = len(c.diff) - len(shape) // end can move the diffs of element i
+	*r = elementRun{kind: r.kind, first: i, n: 1, start: start, shape: shape}
+}
+
+// end ends the run, summarizing its diffs if it is long enough.
+func (r *elementRun) end(c *cmp) {
+	end := r.start + len(r.shape)
+	if r.n == 0 || r.n < SummarizeRepeatedDiffs {
+		if len(r.removedDiff) > 0 {
+			c.diff = append(c.diff[:end], append(r.removedDiff, c.diff[end:]...)...)
+			c.details = append(c.details[:end], append(r.removedDetails, c.details[end:]...)...)
+		}
+		*r = elementRun{kind: r.kind}
+		return
+	}
+
+	// Replace the diffs of the first element, keeping any diffs after them
+	path := append([]string(nil), c.buff...)
+	path = append(path, fmt.Sprintf("%s[%d..%d]", r.kind, r.first, r.first+r.n-1))
+	if len(r.shape) == 1 && r.shape[0] != "" {
+		path = append(path, r.shape[0])
+	}
+	summary := Difference{Path: path, text: fmt.Sprintf("%s: %d elements differ", joinPath(path), r.n)}
+	c.diff = append(c.diff[:r.start], append([]string{summary.text}, c.diff[end:]...)...)
+	c.details = append(c.details[:r.start], append([]Difference{summary}, c.details[end:]...)...)
+	*r = elementRun{kind: r.kind}
+}
+
+// elementSample counts the diffs of slice or array elements after the first
+// SampleElementDiffs differing elements, by path relative to the element. The
+// diffs of those elements are removed as they are added so they don't count
+// toward MaxDiff, and when the slice or array ends, one diff is reported per
+// path with the number of elements.
+type elementSample struct {
+	kind   string // "slice" or "array"
+	n      int    // number of differing elements
+	counts map[string]int
+	order  []string // paths in counts in the order they were added
+}
+
+// add adds the element whose diffs start at index start in c.diff.
+func (s *elementSample) add(c *cmp, start int) {
+	if SampleElementDiffs <= 0 || len(c.diff) == start {
+		return
+	}
+	s.n++
+	if s.n <= SampleElementDiffs {
+		return
+	}
+	if s.counts == nil {
+		s.counts = map[string]int{}
+	}
+	for _, p := range c.shape(start) {
+		if s.counts[p] == 0 {
+			s.order = append(s.order, p)
+		}
+		s.counts[p]++
+	}
+	c.diff = c.diff[:start]
+	c.details = c.details[:start]
+}
+
+// end reports the counted diffs.
+func (s *elementSample) end(c *cmp) {
+	for _, p := range s.order {
+		path := append([]string(nil), c.buff...)
+		path = append(path, s.kind+"[*]")
+		if p != "" {
+			path = append(path, p)
+		}
+		d := Difference{Path: path, text: fmt.Sprintf("%s: %d more elements differ", joinPath(path), s.counts[p])}
+		c.diff = append(c.diff, d.text)
+		c.details = append(c.details, d)
+	}
+}
+
+// shape returns the paths of the diffs from index start, relative to the
+// current element: without the current path and element index.
+func (c *cmp) shape(start int) []string {
+	shape := make([]string, 0, len(c.details)-start)
+	for _, d := range c.details[start:] {
+		shape = append(shape, strings.Join(d.Path[len(c.buff)+1:], "."))
+	}
+	return shape
+}
@@ -0,0 +1,32 @@
+package deep
+
+import "reflect"
+
+// transformers are the registered funcs to transform values by type.
+var transformers = map[reflect.Type]func(v reflect.Value) reflect.Value{}
+
+// RegisterTransformer registers func transform to rewrite values of type t
+// before comparing them, like sorting a []string, lowercasing an email
+// address, or rounding an amount of money. transform is called with a value
+// of type t and returns a valid value, of any type, that is compared instead;
+// it must not modify v. Diffs are reported at the same path, with the
+// transformed values. Transformers are not called for values that cannot be
+// interfaced, like unexported struct fields, or for nil values, and they are
+// not called again for the value returned. A nil transform unregisters t.
+//
+// RegisterTransformer is not safe to call concurrently with Equal.
+func RegisterTransformer(t reflect.Type, transform func(v reflect.Value) reflect.Value) {
+	if transform == nil {
+		delete(transformers, t)
+		return
+	}
+	transformers[t] = transform
+}
+
+// transform returns v transformed by the transformer for its type, if any.
+func transform(v reflect.Value) reflect.Value {
+	if fn, ok := transformers[v.Type()]; ok && v.CanInterface() {
+		return fn(v)
+	}
+	return v
+}
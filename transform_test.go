@@ -0,0 +1,65 @@
+package deep_test
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+type email string
+
+type money float64
+
+func TestRegisterTransformer(t *testing.T) {
+	stringsType := reflect.TypeOf([]string(nil))
+	emailType := reflect.TypeOf(email(""))
+	moneyType := reflect.TypeOf(money(0))
+	deep.RegisterTransformer(stringsType, func(v reflect.Value) reflect.Value {
+		s := append([]string(nil), v.Interface().([]string)...)
+		sort.Strings(s)
+		return reflect.ValueOf(s)
+	})
+	deep.RegisterTransformer(emailType, func(v reflect.Value) reflect.Value {
+		return reflect.ValueOf(strings.ToLower(v.String()))
+	})
+	deep.RegisterTransformer(moneyType, func(v reflect.Value) reflect.Value {
+		return reflect.ValueOf(int64(math.Round(v.Float() * 100))) // cents
+	})
+	defer deep.RegisterTransformer(stringsType, nil)
+	defer deep.RegisterTransformer(emailType, nil)
+	defer deep.RegisterTransformer(moneyType, nil)
+
+	type T struct {
+		Tags  []string
+		Email email
+		Price money
+	}
+	a := T{Tags: []string{"b", "a"}, Email: "Foo@Example.com", Price: 1.001}
+	b := T{Tags: []string{"a", "b"}, Email: "foo@example.com", Price: 0.999}
+	if diff := deep.Equal(a, b); diff != nil {
+		t.Error(diff)
+	}
+	if a.Tags[0] != "b" {
+		t.Error("value modified")
+	}
+
+	b.Tags = []string{"a", "c"}
+	b.Price = 2
+	diff := deep.Equal(a, b)
+	want := []string{
+		"Tags.slice[1]: b != c",
+		"Price: 100 != 200",
+	}
+	if len(diff) != len(want) {
+		t.Fatalf("got %d diffs, expected %d: %v", len(diff), len(want), diff)
+	}
+	for i := range want {
+		if diff[i] != want[i] {
+			t.Errorf("got %q, expected %q", diff[i], want[i])
+		}
+	}
+}
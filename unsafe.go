@@ -0,0 +1,28 @@
+package deep
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// addressable returns v or, if v is not addressable, an addressable copy of v
+// so its unexported fields can be read with exportField. v is returned as-is
+// if it cannot be copied because it was read from an unexported field.
+func addressable(v reflect.Value) reflect.Value {
+	if v.CanAddr() || !v.CanInterface() {
+		return v
+	}
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+	return c
+}
+
+// exportField returns struct field v, read from an unexported field, as if it
+// was read from an exported field, so it can be used with Interface and its
+// methods can be called. See AllowUnsafe.
+func exportField(v reflect.Value) reflect.Value {
+	if v.CanInterface() || !v.CanAddr() {
+		return v
+	}
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+}
@@ -0,0 +1,81 @@
+package deep
+
+import (
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var urlType = reflect.TypeOf(url.URL{})
+
+// equalsURL compares url.URL values a and b by their normalized forms, unless
+// StrictURLs is true, and prints the URLs, with passwords redacted, in diffs.
+func (c *cmp) equalsURL(a, b reflect.Value) {
+	aURL, bURL := a.Interface().(url.URL), b.Interface().(url.URL)
+	if StrictURLs {
+		if aURL.String() != bURL.String() {
+			c.saveDiff(aURL.Redacted(), bURL.Redacted())
+		}
+		return
+	}
+	if normalizeURL(aURL) != normalizeURL(bURL) {
+		c.saveDiff(aURL.Redacted(), bURL.Redacted())
+	}
+}
+
+// normalizeURL returns u as a string with the scheme and host in lower case,
+// the path and fragment escapes normalized by normalizeEscapes, and the query
+// parameters sorted by key and, unless MultiValueOrder is true, by value.
+func normalizeURL(u url.URL) string {
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.RawPath = normalizeEscapes(u.EscapedPath())
+	u.RawFragment = normalizeEscapes(u.EscapedFragment())
+	if u.RawQuery != "" {
+		q := u.Query()
+		if !MultiValueOrder {
+			for _, v := range q {
+				sort.Strings(v)
+			}
+		}
+		u.RawQuery = q.Encode() // sorted by key
+	}
+	return u.String()
+}
+
+// normalizeEscapes decodes the percent-encoded unreserved characters in s and
+// upper cases the hex digits of the other escapes, as RFC 3986 §6.2.2 does.
+// Escaped reserved characters, like "%2F", are not equivalent to the literal
+// ones, so they stay escaped.
+func normalizeEscapes(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i+2 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		n, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+		if err != nil {
+			b.WriteByte(s[i])
+			continue
+		}
+		if ch := byte(n); isUnreserved(ch) {
+			b.WriteByte(ch)
+		} else {
+			b.WriteString(strings.ToUpper(s[i : i+3]))
+		}
+		i += 2
+	}
+	return b.String()
+}
+
+// isUnreserved returns true if ch is an unreserved character in RFC 3986.
+func isUnreserved(ch byte) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || '0' <= ch && ch <= '9' ||
+		ch == '-' || ch == '.' || ch == '_' || ch == '~'
+}
@@ -0,0 +1,185 @@
+package deep
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Visitor is called by Walk for each value. path is the path of the value as
+// it is printed in diffs, like "Address.City" or "map[foo].slice[0]", which is
+// empty for the root value. If the visitor returns false, Walk does not walk
+// the fields, keys, or elements of the value.
+type Visitor func(path string, v reflect.Value) bool
+
+// Walk walks v like Equal, calling visitor for each value: structs, their
+// fields, maps, their values, slices and arrays, their elements, and so on.
+// It honors the same options and struct tags: unexported fields are not
+// walked unless CompareUnexportedFields is true, fields with the tag
+// `deep:"-"` or rejected by FieldFilter are not walked, types with an Equal or
+// Compare method (if UseEqualMethod is true) are walked as one value, and so
+// on. Because Walk has only one value, fields that Equal skips if they are
+// zero, with IgnoreZeroFields or the tag `deep:"optional"`, are not walked if
+// they are zero in v. Pointers and interfaces are dereferenced, so visitor is
+// called with their values; nil pointers and interfaces are visited as-is. A
+// pointer, map, or slice that is already being walked, like the first node of
+// a cyclic linked list, is visited but not walked again. Map keys are walked
+// in sorted order. Walking stops at MaxDepth levels, if greater than zero.
+//
+// Walk is useful for building analyzers, like PII scanners, that are consistent
+// with how Equal compares values.
+func Walk(v interface{}, visitor Visitor) {
+	c := newCmp(nil)
+	defer c.release()
+	w := walker{
+		c: c,
+		visit: func(path []string, v reflect.Value, redacted, cycle bool) bool {
+			return visitor(strings.Join(path, "."), v)
+		},
+	}
+	w.walk(reflect.ValueOf(v), 0)
+}
+
+// walker walks values for Walk and Dump. Values are redacted, like in diffs,
+// if redacted is true when visit is called. If cycle is true, v is a pointer,
+// map, or slice that is already being walked, and it is not walked again.
+type walker struct {
+	c          *cmp // flags
+	visit      func(path []string, v reflect.Value, redacted, cycle bool) bool
+	buff       []string
+	visiting   map[visit]bool // pointers, maps, and slices being walked
+	zeroFields bool           // walk zero fields that Equal can skip
+}
+
+func (w *walker) walk(v reflect.Value, level int) {
+	if MaxDepth > 0 && level > MaxDepth {
+		return
+	}
+	cycle := false
+	for (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.IsNil() {
+		if v.Kind() == reflect.Ptr && hasCompareMethod(v) {
+			break // compared with Compare method, like *big.Int
+		}
+		if v.Kind() == reflect.Ptr {
+			if cycle = !w.enter(v); cycle {
+				break
+			}
+			defer w.leave(v)
+		}
+		v = v.Elem()
+	}
+	if (v.Kind() == reflect.Map || v.Kind() == reflect.Slice) && !v.IsNil() && !cycle {
+		if cycle = !w.enter(v); !cycle {
+			defer w.leave(v)
+		}
+	}
+	if v.IsValid() {
+		t := v.Type()
+		if IgnoreSyncTypes && t.Kind() == reflect.Struct && (t.PkgPath() == "sync" || t.PkgPath() == "sync/atomic") {
+			return
+		}
+	}
+	w.c.buff = w.buff
+	if w.c.ignorePaths != nil && w.c.matchPaths(w.c.ignorePaths) {
+		return
+	}
+	if v.IsValid() && (w.c.redactTypes[v.Type()] || w.c.matchPaths(w.c.redactPaths)) {
+		w.c.redact++
+		defer func() { w.c.redact-- }()
+	}
+	if !w.visit(w.buff, v, w.c.redact > 0, cycle) || cycle {
+		return
+	}
+	if !v.IsValid() {
+		return
+	}
+	if hasCompareMethod(v) || hasEqualMethod(v) {
+		return // compared with Equal or Compare method
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		isProto := IgnoreProtoInternals && methodsOf(reflect.PtrTo(t)).protoMessage
+		generated := w.c.flag[FLAG_GENERATED_STRUCTS]
+		for i := 0; i < v.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" && !CompareUnexportedFields {
+				continue
+			}
+			if (isProto || generated) && (field.PkgPath != "" || strings.HasPrefix(field.Name, "XXX_")) {
+				continue
+			}
+			if field.Tag.Get("deep") == "-" {
+				continue
+			}
+			if FieldFilter != nil && !FieldFilter(field, t) {
+				continue
+			}
+			opts := tagOptions(field)
+			if _, optional := opts["optional"]; !w.zeroFields && (IgnoreZeroFields || optional) && v.Field(i).IsZero() {
+				continue
+			}
+			_, redact := opts["redact"]
+			if redact {
+				w.c.redact++
+			}
+			w.buff = append(w.buff, fieldName(field))
+			w.walk(v.Field(i), level+1)
+			w.buff = w.buff[:len(w.buff)-1]
+			if redact {
+				w.c.redact--
+			}
+		}
+	case reflect.Map:
+		// Skip keys like NaN, which cannot be looked up
+		keep := func(k reflect.Value) bool { return !unequalKey(k) }
+		for _, e := range mapEntries(v, keep) {
+			w.buff = append(w.buff, e.name)
+			w.walk(e.val, level+1)
+			w.buff = w.buff[:len(w.buff)-1]
+		}
+	case reflect.Slice, reflect.Array:
+		kind := "slice"
+		if v.Kind() == reflect.Array {
+			kind = "array"
+		}
+		for i := 0; i < v.Len(); i++ {
+			w.buff = append(w.buff, fmt.Sprintf("%s[%d]", kind, i))
+			w.walk(v.Index(i), level+1)
+			w.buff = w.buff[:len(w.buff)-1]
+		}
+	}
+}
+
+// enter returns true if v, a non-nil pointer, map, or slice, is not already
+// being walked, and marks it as being walked until leave is called.
+func (w *walker) enter(v reflect.Value) bool {
+	k := visit{a: v.Pointer(), t: v.Type()}
+	if w.visiting[k] {
+		return false
+	}
+	if w.visiting == nil {
+		w.visiting = map[visit]bool{}
+	}
+	w.visiting[k] = true
+	return true
+}
+
+func (w *walker) leave(v reflect.Value) {
+	delete(w.visiting, visit{a: v.Pointer(), t: v.Type()})
+}
+
+// hasCompareMethod returns true if v is compared with its Compare or Cmp
+// method, like in equals.
+func hasCompareMethod(v reflect.Value) bool {
+	return UseEqualMethod && methodsOf(v.Type()).compare >= 0 && v.Kind() != reflect.Interface &&
+		!(v.Kind() == reflect.Ptr && v.IsNil()) && v.CanInterface()
+}
+
+// hasEqualMethod returns true if struct v is compared with its Equal method,
+// like in equals.
+func hasEqualMethod(v reflect.Value) bool {
+	i := methodsOf(v.Type()).equal
+	return UseEqualMethod && v.Kind() == reflect.Struct && i >= 0 && v.Method(i).CanInterface()
+}
@@ -0,0 +1,145 @@
+package deep_test
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+)
+
+func TestWalk(t *testing.T) {
+	type Address struct {
+		City    string
+		private string
+	}
+	type User struct {
+		Name    string
+		Emails  []string
+		Address *Address
+		Tags    map[string]int
+		Created time.Time
+		Ignored string `deep:"-"`
+		Manager *User
+	}
+	u := User{
+		Name:    "foo",
+		Emails:  []string{"a@x", "b@x"},
+		Address: &Address{City: "NYC"},
+		Tags:    map[string]int{"z": 1, "a": 2},
+		Created: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	var got []string
+	deep.Walk(u, func(path string, v reflect.Value) bool {
+		got = append(got, fmt.Sprintf("%s=%s", path, v.Kind()))
+		return true
+	})
+	expect := []string{
+		"=struct",
+		"Name=string",
+		"Emails=slice",
+		"Emails.slice[0]=string",
+		"Emails.slice[1]=string",
+		"Address=struct",
+		"Address.City=string",
+		"Tags=map",
+		"Tags.map[a]=int",
+		"Tags.map[z]=int",
+		"Created=struct",
+		"Manager=ptr",
+	}
+	if diff := deep.Equal(got, expect); diff != nil {
+		t.Errorf("%s\n%s", strings.Join(got, "\n"), diff)
+	}
+
+	// Skip children
+	got = nil
+	deep.Walk(&u, func(path string, v reflect.Value) bool {
+		got = append(got, path)
+		return path != "Emails" && path != "Tags" && path != "Address"
+	})
+	expect = []string{"", "Name", "Emails", "Address", "Tags", "Created", "Manager"}
+	if diff := deep.Equal(got, expect); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestWalkCycle(t *testing.T) {
+	type Node struct {
+		Name string
+		Next *Node
+	}
+	n := &Node{Name: "a"}
+	n.Next = &Node{Name: "b", Next: n}
+
+	var got []string
+	deep.Walk(n, func(path string, v reflect.Value) bool {
+		got = append(got, fmt.Sprintf("%s=%s", path, v.Kind()))
+		return true
+	})
+	expect := []string{
+		"=struct",
+		"Name=string",
+		"Next=struct",
+		"Next.Name=string",
+		"Next.Next=ptr",
+	}
+	if diff := deep.Equal(got, expect); diff != nil {
+		t.Errorf("%s\n%s", strings.Join(got, "\n"), diff)
+	}
+}
+
+func TestWalkSkippedFields(t *testing.T) {
+	defer func(f func(reflect.StructField, reflect.Type) bool) { deep.FieldFilter = f }(deep.FieldFilter)
+	deep.FieldFilter = func(f reflect.StructField, _ reflect.Type) bool {
+		return f.Name != "Filtered"
+	}
+	type T struct {
+		Name     string
+		Filtered string
+		Optional int `deep:"optional"`
+		Set      int `deep:"optional"`
+	}
+
+	var got []string
+	deep.Walk(T{Name: "a", Filtered: "b", Set: 1}, func(path string, v reflect.Value) bool {
+		got = append(got, path)
+		return true
+	})
+	expect := []string{"", "Name", "Set"}
+	if diff := deep.Equal(got, expect); diff != nil {
+		t.Errorf("%s\n%s", strings.Join(got, "\n"), diff)
+	}
+}
+
+func TestWalkMethods(t *testing.T) {
+	// Equal of the embedded time.Time does not compare the struct
+	type Event struct {
+		time.Time
+		Name string
+	}
+	var got []string
+	deep.Walk(Event{Name: "x"}, func(path string, v reflect.Value) bool {
+		got = append(got, path)
+		return true
+	})
+	expect := []string{"", "Time", "Name"}
+	if diff := deep.Equal(got, expect); diff != nil {
+		t.Errorf("%s\n%s", strings.Join(got, "\n"), diff)
+	}
+
+	// Keys like NaN cannot be looked up
+	got = nil
+	deep.Walk(map[float64]int{math.NaN(): 1, 2: 2}, func(path string, v reflect.Value) bool {
+		got = append(got, fmt.Sprintf("%s=%s", path, v.Kind()))
+		return true
+	})
+	expect = []string{"=map", "map[2]=int"}
+	if diff := deep.Equal(got, expect); diff != nil {
+		t.Errorf("%s\n%s", strings.Join(got, "\n"), diff)
+	}
+}
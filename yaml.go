@@ -0,0 +1,22 @@
+package deep
+
+// EqualYAML compares YAML documents a and b and returns a list of differences,
+// or nil if there are none. It mirrors EqualJSON, but because this package has
+// no dependencies, the caller provides the YAML unmarshal func, like Unmarshal
+// from gopkg.in/yaml.v3 or sigs.k8s.io/yaml:
+//
+//	diff, err := deep.EqualYAML(a, b, yaml.Unmarshal)
+//
+// Both documents are unmarshaled into an interface{} and compared like Equal,
+// so differences are reported by path, like "map[spec].map[replicas]: 1 != 2".
+// An error is returned if either document cannot be unmarshaled.
+func EqualYAML(a, b []byte, unmarshal func([]byte, interface{}) error) ([]string, error) {
+	var aDoc, bDoc interface{}
+	if err := unmarshal(a, &aDoc); err != nil {
+		return nil, err
+	}
+	if err := unmarshal(b, &bDoc); err != nil {
+		return nil, err
+	}
+	return Equal(aDoc, bDoc), nil
+}
@@ -0,0 +1,37 @@
+package deep_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+// JSON is YAML, so json.Unmarshal stands in for a YAML package
+func TestEqualYAML(t *testing.T) {
+	a := []byte(`{"spec": {"replicas": 1, "image": "nginx"}}`)
+	b := []byte(`{"spec": {"image": "nginx", "replicas": 1}}`)
+	diff, err := deep.EqualYAML(a, b, json.Unmarshal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != 0 {
+		t.Fatalf("expected 0 diff, got %d: %s", len(diff), diff)
+	}
+
+	b = []byte(`{"spec": {"image": "nginx", "replicas": 2}}`)
+	diff, err = deep.EqualYAML(a, b, json.Unmarshal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != 1 {
+		t.Fatalf("expected 1 diff, got %d: %s", len(diff), diff)
+	}
+	if diff[0] != "map[spec].map[replicas]: 1 != 2" {
+		t.Errorf("wrong diff: %s", diff[0])
+	}
+
+	if _, err := deep.EqualYAML(a, []byte(`{`), json.Unmarshal); err == nil {
+		t.Error("expected error")
+	}
+}